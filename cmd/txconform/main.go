@@ -0,0 +1,306 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// txconform checks the transaction codec against the blocks served by a live
+// RPC provider. Every transaction is decoded from the provider's JSON,
+// re-encoded and compared field by field, producing a conformance report per
+// transaction type.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	rpcURL    = flag.String("rpc", "", "RPC endpoint of the provider to check against")
+	fromBlock = flag.Int64("from", -1, "first block to check (default: latest)")
+	toBlock   = flag.Int64("to", -1, "last block to check (default: same as -from)")
+	verbose   = flag.Bool("verbose", false, "print the differences of every failing transaction")
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage:", os.Args[0], "-rpc <url> [-from <block>] [-to <block>] [-verbose]")
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr, `
+Decodes every transaction in the given block range as served by the provider,
+re-encodes it and reports the fields that don't round trip, per transaction type.`)
+	}
+}
+
+func main() {
+	flag.Parse()
+	if *rpcURL == "" {
+		fmt.Fprintln(os.Stderr, "Error: missing -rpc endpoint")
+		flag.Usage()
+		os.Exit(2)
+	}
+	client, err := rpc.DialContext(context.Background(), *rpcURL)
+	if err != nil {
+		die(err)
+	}
+	defer client.Close()
+
+	from, to, err := blockRange(client, *fromBlock, *toBlock)
+	if err != nil {
+		die(err)
+	}
+	report := newReport()
+	for number := from; number <= to; number++ {
+		txs, err := blockTransactions(client, number)
+		if err != nil {
+			die(err)
+		}
+		for _, raw := range txs {
+			report.add(checkTransaction(raw))
+		}
+	}
+	report.print(os.Stdout, *verbose)
+	if report.failed() {
+		os.Exit(1)
+	}
+}
+
+func die(args ...interface{}) {
+	fmt.Fprintln(os.Stderr, args...)
+	os.Exit(1)
+}
+
+// blockRange resolves the block range to check, defaulting to the latest block.
+func blockRange(client *rpc.Client, from, to int64) (uint64, uint64, error) {
+	if from < 0 {
+		var head hexutil.Uint64
+		if err := client.CallContext(context.Background(), &head, "eth_blockNumber"); err != nil {
+			return 0, 0, err
+		}
+		from = int64(head)
+	}
+	if to < 0 {
+		to = from
+	}
+	if from > to {
+		return 0, 0, fmt.Errorf("invalid block range: %d > %d", from, to)
+	}
+	return uint64(from), uint64(to), nil
+}
+
+// blockTransactions retrieves the raw JSON of the transactions in a block.
+func blockTransactions(client *rpc.Client, number uint64) ([]json.RawMessage, error) {
+	var block *struct {
+		Transactions []json.RawMessage `json:"transactions"`
+	}
+	if err := client.CallContext(context.Background(), &block, "eth_getBlockByNumber", hexutil.EncodeUint64(number), true); err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+	return block.Transactions, nil
+}
+
+// contextFields are the fields providers attach to transactions served within
+// a block, which are not part of the transaction encoding.
+var contextFields = map[string]bool{
+	"blockHash":        true,
+	"blockNumber":      true,
+	"transactionIndex": true,
+}
+
+// result is the outcome of checking a single transaction.
+type result struct {
+	typ   string   // Transaction type as reported by the provider
+	hash  string   // Transaction hash as reported by the provider
+	err   error    // Decoding failure, if any
+	diffs []string // Fields that did not round trip
+}
+
+// checkTransaction decodes a transaction from the provider's JSON, re-encodes
+// it both as JSON and in its binary form, and reports any differences.
+func checkTransaction(raw json.RawMessage) *result {
+	var provided map[string]interface{}
+	if err := json.Unmarshal(raw, &provided); err != nil {
+		return &result{typ: "invalid", err: err}
+	}
+	res := &result{typ: "0x0", hash: fmt.Sprint(provided["hash"])}
+	if typ, ok := provided["type"].(string); ok {
+		res.typ = typ
+	}
+	var tx types.Transaction
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		res.err = err
+		return res
+	}
+	// Ensure the binary encoding round trips
+	blob, err := tx.MarshalBinary()
+	if err != nil {
+		res.err = err
+		return res
+	}
+	var dec types.Transaction
+	if err := dec.UnmarshalBinary(blob); err != nil {
+		res.diffs = append(res.diffs, fmt.Sprintf("binary: failed to decode re-encoded transaction: %v", err))
+	} else if dec.Hash() != tx.Hash() {
+		res.diffs = append(res.diffs, fmt.Sprintf("binary: hash mismatch after round trip: %v != %v", dec.Hash(), tx.Hash()))
+	}
+	// Ensure the JSON encoding round trips
+	enc, err := json.Marshal(&tx)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	var encoded map[string]interface{}
+	if err := json.Unmarshal(enc, &encoded); err != nil {
+		res.err = err
+		return res
+	}
+	// Providers add fields derived from the transaction, check the served ones
+	derived := make(map[string]interface{})
+	if sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), &tx); err == nil {
+		derived["from"] = strings.ToLower(sender.Hex())
+	}
+	if tx.Protected() {
+		derived["chainId"] = (*hexutil.Big)(tx.ChainId()).String()
+	}
+	if tx.Type() != types.LegacyTxType {
+		v, _, _ := tx.RawSignatureValues()
+		derived["yParity"] = (*hexutil.Big)(v).String()
+	}
+	for key, value := range derived {
+		if _, ok := provided[key]; ok {
+			encoded[key] = value
+		}
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		// Providers report the effective gas price, which depends on the block
+		delete(provided, "gasPrice")
+	}
+	res.diffs = append(res.diffs, diffFields(provided, encoded)...)
+	return res
+}
+
+// diffFields compares the provider's rendering of a transaction with the
+// re-encoded one, ignoring block context fields and unset fields.
+func diffFields(provided, encoded map[string]interface{}) []string {
+	keys := make(map[string]struct{})
+	for key := range provided {
+		keys[key] = struct{}{}
+	}
+	for key := range encoded {
+		keys[key] = struct{}{}
+	}
+	var diffs []string
+	for key := range keys {
+		if contextFields[key] {
+			continue
+		}
+		have, want := normalize(encoded[key]), normalize(provided[key])
+		switch {
+		case want == "null" && have == "null":
+		case want == "null":
+			diffs = append(diffs, fmt.Sprintf("%s: not served by provider, encoded as %s", key, have))
+		case have == "null":
+			diffs = append(diffs, fmt.Sprintf("%s: served as %s, missing after re-encoding", key, want))
+		case have != want:
+			diffs = append(diffs, fmt.Sprintf("%s: served as %s, re-encoded as %s", key, want, have))
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+// normalize renders a JSON value in a canonical, case insensitive form.
+func normalize(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	blob, _ := json.Marshal(v)
+	return strings.ToLower(string(blob))
+}
+
+// typeReport collects the results of a single transaction type.
+type typeReport struct {
+	total    int
+	failures []*result
+}
+
+// report collects the results of all checked transactions, by type.
+type report struct {
+	types map[string]*typeReport
+}
+
+func newReport() *report {
+	return &report{types: make(map[string]*typeReport)}
+}
+
+func (r *report) add(res *result) {
+	tr, ok := r.types[res.typ]
+	if !ok {
+		tr = new(typeReport)
+		r.types[res.typ] = tr
+	}
+	tr.total++
+	if res.err != nil || len(res.diffs) > 0 {
+		tr.failures = append(tr.failures, res)
+	}
+}
+
+func (r *report) failed() bool {
+	for _, tr := range r.types {
+		if len(tr.failures) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *report) print(w io.Writer, verbose bool) {
+	names := make([]string, 0, len(r.types))
+	for name := range r.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "%-8s %8s %8s %8s\n", "TYPE", "TOTAL", "PASSED", "FAILED")
+	for _, name := range names {
+		tr := r.types[name]
+		fmt.Fprintf(w, "%-8s %8d %8d %8d\n", name, tr.total, tr.total-len(tr.failures), len(tr.failures))
+	}
+	if !verbose {
+		return
+	}
+	for _, name := range names {
+		for _, res := range r.types[name].failures {
+			fmt.Fprintf(w, "\ntx %s (type %s):\n", res.hash, name)
+			if res.err != nil {
+				fmt.Fprintf(w, "  decode: %v\n", res.err)
+			}
+			for _, diff := range res.diffs {
+				fmt.Fprintf(w, "  %s\n", diff)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// servedTransactions returns the transactions of a test block as rendered by
+// the RPC server.
+func servedTransactions(t *testing.T) []json.RawMessage {
+	var (
+		config = params.AllEthashProtocolChanges
+		signer = types.LatestSigner(config)
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		to     = common.Address{0xde, 0xad}
+	)
+	txdata := []types.TxData{
+		&types.LegacyTx{Nonce: 0, GasPrice: big.NewInt(1), Gas: 21000, To: &to, Value: big.NewInt(1)},
+		&types.AccessListTx{
+			ChainID: config.ChainID, Nonce: 1, GasPrice: big.NewInt(1), Gas: 30000, To: &to,
+			AccessList: types.AccessList{{Address: to, StorageKeys: []common.Hash{{0x01}}}},
+		},
+		&types.DynamicFeeTx{ChainID: config.ChainID, Nonce: 2, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 50000, Data: []byte{0xc0, 0xde}},
+	}
+	var txs types.Transactions
+	for _, data := range txdata {
+		tx, err := types.SignNewTx(key, signer, data)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		txs = append(txs, tx)
+	}
+	header := &types.Header{Number: big.NewInt(1), Difficulty: common.Big0, BaseFee: big.NewInt(1)}
+	block := types.NewBlock(header, txs, nil, nil, trie.NewStackTrie(nil))
+
+	var served []json.RawMessage
+	for i := range txs {
+		blob, err := json.Marshal(ethapi.NewRPCTransactionFromBlockIndex(block, uint64(i), config))
+		if err != nil {
+			t.Fatalf("failed to marshal transaction: %v", err)
+		}
+		served = append(served, blob)
+	}
+	return served
+}
+
+func TestCheckTransaction(t *testing.T) {
+	for i, raw := range servedTransactions(t) {
+		res := checkTransaction(raw)
+		if res.err != nil {
+			t.Errorf("tx %d: failed to decode: %v", i, res.err)
+		}
+		if len(res.diffs) > 0 {
+			t.Errorf("tx %d: unexpected differences: %v", i, res.diffs)
+		}
+	}
+}
+
+func TestCheckTransactionMismatch(t *testing.T) {
+	raw := servedTransactions(t)[1]
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatal(err)
+	}
+	fields["from"] = "0x0000000000000000000000000000000000000001"
+	fields["extra"] = "0x1"
+	tampered, _ := json.Marshal(fields)
+
+	res := checkTransaction(tampered)
+	if res.err != nil {
+		t.Fatalf("failed to decode: %v", res.err)
+	}
+	if res.typ != "0x1" {
+		t.Errorf("type mismatch: have %s, want %s", res.typ, "0x1")
+	}
+	if len(res.diffs) != 2 {
+		t.Fatalf("difference count mismatch: have %v, want 2", res.diffs)
+	}
+	// Unknown transaction types fail to decode and are grouped by type
+	fields["type"] = "0x7e"
+	unknown, _ := json.Marshal(fields)
+	if res := checkTransaction(unknown); res.err == nil || res.typ != "0x7e" {
+		t.Errorf("unexpected result for unknown type: %+v", res)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ReadAddressLabel retrieves the serialized label attached to an address.
+func ReadAddressLabel(db ethdb.KeyValueReader, address common.Address) []byte {
+	data, _ := db.Get(addressLabelKey(address))
+	return data
+}
+
+// WriteAddressLabel stores the serialized label attached to an address.
+func WriteAddressLabel(db ethdb.KeyValueWriter, address common.Address, label []byte) {
+	if err := db.Put(addressLabelKey(address), label); err != nil {
+		log.Crit("Failed to store address label", "err", err)
+	}
+}
+
+// DeleteAddressLabel removes the label attached to an address.
+func DeleteAddressLabel(db ethdb.KeyValueWriter, address common.Address) {
+	if err := db.Delete(addressLabelKey(address)); err != nil {
+		log.Crit("Failed to delete address label", "err", err)
+	}
+}
+
+// ReadAllAddressLabels retrieves all the serialized address labels stored in
+// the database.
+func ReadAllAddressLabels(db ethdb.Iteratee) map[common.Address][]byte {
+	labels := make(map[common.Address][]byte)
+
+	it := db.NewIterator(addressLabelPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		if key := it.Key(); len(key) == len(addressLabelPrefix)+common.AddressLength {
+			labels[common.BytesToAddress(key[len(addressLabelPrefix):])] = common.CopyBytes(it.Value())
+		}
+	}
+	return labels
+}
@@ -121,6 +121,8 @@ var (
 
 	CliqueSnapshotPrefix = []byte("clique-")
 
+	addressLabelPrefix = []byte("zecrey-label-") // addressLabelPrefix + address -> address label
+
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
 )
@@ -239,6 +241,11 @@ func genesisStateSpecKey(hash common.Hash) []byte {
 	return append(genesisPrefix, hash.Bytes()...)
 }
 
+// addressLabelKey = addressLabelPrefix + address
+func addressLabelKey(address common.Address) []byte {
+	return append(addressLabelPrefix, address.Bytes()...)
+}
+
 // accountTrieNodeKey = trieNodeAccountPrefix + nodePath.
 func accountTrieNodeKey(path []byte) []byte {
 	return append(trieNodeAccountPrefix, path...)
@@ -113,6 +113,45 @@ func IntrinsicGas(data []byte, accessList types.AccessList, isContractCreation b
 	return gas, nil
 }
 
+// IntrinsicGasCost is the intrinsic gas of a message, itemised by source.
+type IntrinsicGasCost struct {
+	Base       uint64 // Flat cost of a transaction or contract creation
+	Calldata   uint64 // Cost of the zero and non-zero calldata bytes
+	InitCode   uint64 // Cost of the init code words of a contract creation (EIP-3860)
+	AccessList uint64 // Cost of the pre-declared addresses and storage slots (EIP-2930)
+	Total      uint64 // Sum of all the above, as charged by IntrinsicGas
+}
+
+// IntrinsicGasBreakdown computes the intrinsic gas of a message with the given
+// data and access list under the given fork rules, itemised by source.
+func IntrinsicGasBreakdown(data []byte, accessList types.AccessList, isContractCreation bool, rules params.Rules) (*IntrinsicGasCost, error) {
+	// Derive each component as the difference between successive inclusions,
+	// so that the total is always exactly what IntrinsicGas charges.
+	base, err := IntrinsicGas(nil, nil, isContractCreation, rules.IsHomestead, rules.IsIstanbul, rules.IsShanghai)
+	if err != nil {
+		return nil, err
+	}
+	calldata, err := IntrinsicGas(data, nil, isContractCreation, rules.IsHomestead, rules.IsIstanbul, false)
+	if err != nil {
+		return nil, err
+	}
+	initcode, err := IntrinsicGas(data, nil, isContractCreation, rules.IsHomestead, rules.IsIstanbul, rules.IsShanghai)
+	if err != nil {
+		return nil, err
+	}
+	total, err := IntrinsicGas(data, accessList, isContractCreation, rules.IsHomestead, rules.IsIstanbul, rules.IsShanghai)
+	if err != nil {
+		return nil, err
+	}
+	return &IntrinsicGasCost{
+		Base:       base,
+		Calldata:   calldata - base,
+		InitCode:   initcode - calldata,
+		AccessList: total - initcode,
+		Total:      total,
+	}, nil
+}
+
 // toWordSize returns the ceiled word size required for init code payment calculation.
 func toWordSize(size uint64) uint64 {
 	if size > math.MaxUint64-31 {
@@ -0,0 +1,68 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestIntrinsicGasBreakdown(t *testing.T) {
+	var (
+		data       = []byte{0x00, 0x01, 0x02}
+		accessList = types.AccessList{{Address: common.Address{0x01}, StorageKeys: []common.Hash{{0x01}, {0x02}}}}
+		frontier   = params.Rules{}
+		istanbul   = params.TestChainConfig.Rules(big.NewInt(0), false, 0)
+		shanghai   = params.TestChainConfig.Rules(big.NewInt(0), true, 0)
+	)
+	istanbul.IsShanghai = false
+	shanghai.IsShanghai = true
+
+	tests := []struct {
+		data       []byte
+		accessList types.AccessList
+		creation   bool
+		rules      params.Rules
+		want       IntrinsicGasCost
+	}{
+		// Plain transfer, no data
+		{nil, nil, false, istanbul, IntrinsicGasCost{Base: params.TxGas, Total: params.TxGas}},
+		// Frontier calldata pricing, creation not yet surcharged
+		{data, nil, true, frontier, IntrinsicGasCost{Base: params.TxGas, Calldata: 4 + 2*68, Total: params.TxGas + 4 + 2*68}},
+		// Istanbul calldata pricing with an access list
+		{data, accessList, false, istanbul, IntrinsicGasCost{Base: params.TxGas, Calldata: 4 + 2*16, AccessList: 2400 + 2*1900, Total: params.TxGas + 4 + 2*16 + 2400 + 2*1900}},
+		// Shanghai contract creation charges init code words
+		{data, nil, true, shanghai, IntrinsicGasCost{Base: params.TxGasContractCreation, Calldata: 4 + 2*16, InitCode: params.InitCodeWordGas, Total: params.TxGasContractCreation + 4 + 2*16 + params.InitCodeWordGas}},
+	}
+	for i, tt := range tests {
+		have, err := IntrinsicGasBreakdown(tt.data, tt.accessList, tt.creation, tt.rules)
+		if err != nil {
+			t.Fatalf("test %d: failed to compute intrinsic gas: %v", i, err)
+		}
+		if *have != tt.want {
+			t.Errorf("test %d: breakdown mismatch: have %+v, want %+v", i, *have, tt.want)
+		}
+		total, _ := IntrinsicGas(tt.data, tt.accessList, tt.creation, tt.rules.IsHomestead, tt.rules.IsIstanbul, tt.rules.IsShanghai)
+		if have.Total != total {
+			t.Errorf("test %d: total mismatch: have %d, want %d", i, have.Total, total)
+		}
+	}
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/labels"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -243,6 +244,36 @@ func (api *AdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// SetAddressLabel stores or replaces the label attached to the given address.
+func (api *AdminAPI) SetAddressLabel(address common.Address, label labels.Label) (bool, error) {
+	if err := api.eth.labels.Put(address, &label); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetAddressLabel retrieves the label attached to the given address, or null
+// if the address is not labelled.
+func (api *AdminAPI) GetAddressLabel(address common.Address) *labels.Label {
+	return api.eth.labels.Get(address)
+}
+
+// DeleteAddressLabel removes the label attached to the given address, reporting
+// whether there was one to remove.
+func (api *AdminAPI) DeleteAddressLabel(address common.Address) bool {
+	return api.eth.labels.Delete(address)
+}
+
+// ListAddressLabels retrieves all stored address labels, optionally filtered to
+// those carrying the given tag.
+func (api *AdminAPI) ListAddressLabels(tag *string) map[common.Address]*labels.Label {
+	var filter string
+	if tag != nil {
+		filter = *tag
+	}
+	return api.eth.labels.List(filter)
+}
+
 // DebugAPI is the collection of Ethereum full node APIs for debugging the
 // protocol.
 type DebugAPI struct {
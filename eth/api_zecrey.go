@@ -0,0 +1,116 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/labels"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+)
+
+// ZecreyAPI provides aggregated data access APIs for full nodes, saving
+// explorers and wallets from issuing several dependent calls per item.
+type ZecreyAPI struct {
+	eth *Ethereum
+}
+
+// NewZecreyAPI creates a new zecrey API instance.
+func NewZecreyAPI(eth *Ethereum) *ZecreyAPI {
+	return &ZecreyAPI{eth: eth}
+}
+
+// TransactionBundleConfig holds extra parameters for GetTransactionBundle.
+type TransactionBundleConfig struct {
+	// Trace, if set, requests an execution trace of the transaction using
+	// the given tracer configuration. The default struct logger is used if
+	// no tracer is named.
+	Trace *tracers.TraceConfig `json:"trace"`
+}
+
+// TransactionBundle is the combined view of a mined transaction returned by
+// GetTransactionBundle.
+type TransactionBundle struct {
+	Transaction *ethapi.RPCTransaction `json:"transaction"`
+	Receipt     map[string]interface{} `json:"receipt"`
+	Trace       interface{}            `json:"trace,omitempty"`
+
+	// Labels holds the stored labels of the addresses involved in the
+	// transaction (sender, recipient, created contract and log emitters).
+	Labels map[common.Address]*labels.Label `json:"labels,omitempty"`
+}
+
+// GetTransactionBundle returns a mined transaction together with its receipt
+// and, optionally, its execution trace. All parts are derived from the same
+// block, so the result is consistent even if the chain reorganises while the
+// request is being served.
+func (api *ZecreyAPI) GetTransactionBundle(ctx context.Context, hash common.Hash, config *TransactionBundleConfig) (*TransactionBundle, error) {
+	_, blockHash, blockNumber, index := rawdb.ReadTransaction(api.eth.ChainDb(), hash)
+	if blockHash == (common.Hash{}) {
+		// When the transaction doesn't exist, the RPC method should return JSON null
+		// as per specification.
+		return nil, nil
+	}
+	// Pin every subsequent lookup to the block the transaction was found in.
+	block := api.eth.blockchain.GetBlock(blockHash, blockNumber)
+	if block == nil {
+		return nil, fmt.Errorf("block %#x not found", blockHash)
+	}
+	receipts := api.eth.blockchain.GetReceiptsByHash(blockHash)
+	if int(index) >= len(receipts) || int(index) >= len(block.Transactions()) {
+		return nil, fmt.Errorf("transaction index %d out of range for block %#x", index, blockHash)
+	}
+	var (
+		tx     = block.Transactions()[index]
+		signer = types.MakeSigner(api.eth.blockchain.Config(), block.Number())
+	)
+	bundle := &TransactionBundle{
+		Transaction: ethapi.NewRPCTransactionFromBlockIndex(block, index, api.eth.blockchain.Config()),
+		Receipt:     ethapi.MarshalReceipt(receipts[index], blockHash, blockNumber, signer, tx, int(index)),
+	}
+	if labelled := api.eth.labels.GetMany(involvedAddresses(bundle.Transaction.From, tx, receipts[index])); len(labelled) > 0 {
+		bundle.Labels = labelled
+	}
+	if config != nil && config.Trace != nil {
+		trace, err := tracers.TraceTransactionAt(ctx, api.eth.APIBackend, block, int(index), config.Trace)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Trace = trace
+	}
+	return bundle, nil
+}
+
+// involvedAddresses returns the addresses taking part in a mined transaction.
+func involvedAddresses(from common.Address, tx *types.Transaction, receipt *types.Receipt) []common.Address {
+	addresses := []common.Address{from}
+	if to := tx.To(); to != nil {
+		addresses = append(addresses, *to)
+	}
+	if receipt.ContractAddress != (common.Address{}) {
+		addresses = append(addresses, receipt.ContractAddress)
+	}
+	for _, log := range receipt.Logs {
+		addresses = append(addresses, log.Address)
+	}
+	return addresses
+}
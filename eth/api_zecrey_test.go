@@ -0,0 +1,122 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/labels"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestGetTransactionBundle(t *testing.T) {
+	t.Parallel()
+
+	var (
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		emitter = common.HexToAddress("0x1099")
+		signer  = types.LatestSigner(params.TestChainConfig)
+		engine  = ethash.NewFaker()
+		db      = rawdb.NewMemoryDatabase()
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				sender: {Balance: big.NewInt(params.Ether)},
+				// Emits an empty log
+				emitter: {Balance: common.Big0, Code: common.FromHex("60006000a000")},
+			},
+		}
+		txHash common.Hash
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 1, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), emitter, big.NewInt(1), 100_000, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+		txHash = tx.Hash()
+	})
+	chain, err := core.NewBlockChain(db, nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	eth := &Ethereum{chainDb: db, blockchain: chain, labels: labels.NewStore(db)}
+	eth.APIBackend = &EthAPIBackend{eth: eth}
+	if err := eth.labels.Put(emitter, &labels.Label{Name: "emitter", Source: "manual"}); err != nil {
+		t.Fatalf("failed to store label: %v", err)
+	}
+	api := NewZecreyAPI(eth)
+
+	// Unknown transactions are reported as null
+	bundle, err := api.GetTransactionBundle(context.Background(), common.Hash{0x01}, nil)
+	if err != nil || bundle != nil {
+		t.Fatalf("unknown transaction: have %v, %v, want nil bundle", bundle, err)
+	}
+	// Mined transactions come with their receipt and labels, without a trace
+	bundle, err = api.GetTransactionBundle(context.Background(), txHash, nil)
+	if err != nil {
+		t.Fatalf("failed to get bundle: %v", err)
+	}
+	if bundle.Transaction.Hash != txHash {
+		t.Errorf("transaction hash mismatch: have %x, want %x", bundle.Transaction.Hash, txHash)
+	}
+	if have := bundle.Receipt["transactionHash"]; have != txHash {
+		t.Errorf("receipt transaction hash mismatch: have %v, want %x", have, txHash)
+	}
+	if have := bundle.Receipt["blockHash"]; have != blocks[0].Hash() {
+		t.Errorf("receipt block hash mismatch: have %v, want %x", have, blocks[0].Hash())
+	}
+	if label := bundle.Labels[emitter]; label == nil || label.Name != "emitter" {
+		t.Errorf("label mismatch: have %v, want emitter", label)
+	}
+	if bundle.Trace != nil {
+		t.Errorf("unexpected trace: %v", bundle.Trace)
+	}
+	// The trace option traces the transaction with the struct logger by default
+	bundle, err = api.GetTransactionBundle(context.Background(), txHash, &TransactionBundleConfig{Trace: &tracers.TraceConfig{}})
+	if err != nil {
+		t.Fatalf("failed to get traced bundle: %v", err)
+	}
+	blob, ok := bundle.Trace.(json.RawMessage)
+	if !ok {
+		t.Fatalf("trace type mismatch: have %T, want json.RawMessage", bundle.Trace)
+	}
+	var trace logger.ExecutionResult
+	if err := json.Unmarshal(blob, &trace); err != nil {
+		t.Fatalf("failed to decode trace: %v", err)
+	}
+	if receipt := chain.GetReceiptsByHash(blocks[0].Hash())[0]; trace.Gas != receipt.GasUsed {
+		t.Errorf("trace gas mismatch: have %d, want %d", trace.Gas, receipt.GasUsed)
+	}
+	if len(trace.StructLogs) == 0 || trace.StructLogs[len(trace.StructLogs)-2].Op != "LOG0" {
+		t.Errorf("unexpected struct logs: %v", trace.StructLogs)
+	}
+}
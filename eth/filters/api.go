@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -241,6 +242,124 @@ func (api *FilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 	return rpcSub, nil
 }
 
+// blockWithReceiptsQueue is the number of new heads queued for assembly by a
+// newBlocksWithTxsAndReceipts subscription before further heads are dropped.
+const blockWithReceiptsQueue = 128
+
+// BlockWithReceipts is the notification payload of the newBlocksWithTxsAndReceipts
+// subscription. If the body or the receipts of the block could not be retrieved,
+// the block only holds the header fields and the error is reported.
+type BlockWithReceipts struct {
+	Block    map[string]interface{}   `json:"block"`
+	Receipts []map[string]interface{} `json:"receipts"`
+	Error    string                   `json:"error,omitempty"`
+}
+
+// NewBlocksWithTxsAndReceipts sends a notification each time a new block is
+// appended to the chain, carrying the block with its full transactions along
+// with their receipts, so indexers don't need to fetch them separately.
+//
+// The blocks are assembled in the background so slow retrievals don't hold up
+// the event system. Heads arriving while too many blocks are pending are dropped.
+func (api *FilterAPI) NewBlocksWithTxsAndReceipts(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		var (
+			headers    = make(chan *types.Header)
+			queue      = make(chan *types.Header, blockWithReceiptsQueue)
+			headersSub = api.events.SubscribeNewHeads(headers)
+		)
+		fetchCtx, cancel := context.WithCancel(context.Background())
+		go api.notifyBlocksWithReceipts(fetchCtx, notifier, rpcSub.ID, queue)
+
+		for {
+			select {
+			case h := <-headers:
+				select {
+				case queue <- h:
+				default:
+					log.Warn("Dropping block with receipts notification", "number", h.Number, "hash", h.Hash(), "queued", len(queue))
+				}
+			case <-rpcSub.Err():
+				headersSub.Unsubscribe()
+				cancel()
+				return
+			case <-notifier.Closed():
+				headersSub.Unsubscribe()
+				cancel()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// notifyBlocksWithReceipts assembles the blocks of the queued headers and
+// sends them to the subscriber until the context is cancelled.
+func (api *FilterAPI) notifyBlocksWithReceipts(ctx context.Context, notifier *rpc.Notifier, id rpc.ID, queue <-chan *types.Header) {
+	for {
+		select {
+		case h := <-queue:
+			block, err := api.blockWithReceipts(ctx, h)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Warn("Failed to assemble block with receipts", "number", h.Number, "hash", h.Hash(), "err", err)
+				block = &BlockWithReceipts{Block: ethapi.RPCMarshalHeader(h), Error: err.Error()}
+			}
+			notifier.Notify(id, block)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// blockWithReceipts retrieves the body and receipts of the block with the given
+// header and formats them for RPC.
+func (api *FilterAPI) blockWithReceipts(ctx context.Context, header *types.Header) (*BlockWithReceipts, error) {
+	var (
+		backend = api.sys.backend
+		hash    = header.Hash()
+		number  = header.Number.Uint64()
+	)
+	body, err := backend.GetBody(ctx, hash, rpc.BlockNumber(number))
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := backend.GetReceipts(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) != len(body.Transactions) {
+		return nil, fmt.Errorf("receipt count mismatch: have %d, want %d", len(receipts), len(body.Transactions))
+	}
+	block := types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles)
+	if body.Withdrawals != nil {
+		block = block.WithWithdrawals(body.Withdrawals)
+	}
+	config := backend.ChainConfig()
+	fields, err := ethapi.RPCMarshalBlock(block, true, true, config)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		signer    = types.MakeSigner(config, header.Number)
+		formatted = make([]map[string]interface{}, len(receipts))
+	)
+	for i, receipt := range receipts {
+		formatted[i] = ethapi.MarshalReceipt(receipt, hash, number, signer, body.Transactions[i], i)
+	}
+	return &BlockWithReceipts{Block: fields, Receipts: formatted}, nil
+}
+
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
 func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
@@ -353,6 +472,109 @@ func (api *FilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*type
 	return returnLogs(logs), err
 }
 
+// LogContextOptions selects the context attached to each log returned by
+// GetLogsWithContext.
+type LogContextOptions struct {
+	Transaction bool `json:"includeTransaction"`
+	Receipt     bool `json:"includeReceipt"`
+}
+
+// LogReceipt is the summary of the receipt of the transaction that emitted a log.
+type LogReceipt struct {
+	Status            *hexutil.Uint64 `json:"status,omitempty"`
+	Root              hexutil.Bytes   `json:"root,omitempty"`
+	GasUsed           hexutil.Uint64  `json:"gasUsed"`
+	CumulativeGasUsed hexutil.Uint64  `json:"cumulativeGasUsed"`
+}
+
+// LogWithContext is a log along with its parent transaction and receipt.
+type LogWithContext struct {
+	Log         *types.Log             `json:"log"`
+	Transaction *ethapi.RPCTransaction `json:"transaction,omitempty"`
+	Receipt     *LogReceipt            `json:"receipt,omitempty"`
+}
+
+// GetLogsWithContext returns logs matching the given argument like GetLogs, with
+// each log optionally carrying its parent transaction and receipt summary, so
+// callers don't need to look them up one by one. If no options are given, both
+// the transaction and the receipt are included. Pending logs are returned
+// without context.
+func (api *FilterAPI) GetLogsWithContext(ctx context.Context, crit FilterCriteria, opts *LogContextOptions) ([]*LogWithContext, error) {
+	logs, err := api.GetLogs(ctx, crit)
+	if err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &LogContextOptions{Transaction: true, Receipt: true}
+	}
+	var (
+		backend  = api.sys.backend
+		config   = backend.ChainConfig()
+		result   = make([]*LogWithContext, len(logs))
+		block    *types.Block
+		receipts types.Receipts
+	)
+	for i, l := range logs {
+		result[i] = &LogWithContext{Log: l}
+		if !opts.Transaction && !opts.Receipt {
+			continue
+		}
+		// Pending logs don't belong to a block yet, there is no context to load
+		if l.BlockHash == (common.Hash{}) {
+			continue
+		}
+		// Logs are ordered by block, so the context is loaded once per block
+		if block == nil || block.Hash() != l.BlockHash {
+			header, err := backend.HeaderByHash(ctx, l.BlockHash)
+			if err != nil {
+				return nil, err
+			}
+			if header == nil {
+				return nil, fmt.Errorf("block %#x not found", l.BlockHash)
+			}
+			body, err := backend.GetBody(ctx, l.BlockHash, rpc.BlockNumber(l.BlockNumber))
+			if err != nil {
+				return nil, err
+			}
+			block = types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles)
+			if opts.Receipt {
+				if receipts, err = backend.GetReceipts(ctx, l.BlockHash); err != nil {
+					return nil, err
+				}
+				if len(receipts) != len(body.Transactions) {
+					return nil, fmt.Errorf("receipt count mismatch: have %d, want %d", len(receipts), len(body.Transactions))
+				}
+			}
+		}
+		if l.TxIndex >= uint(len(block.Transactions())) {
+			return nil, fmt.Errorf("log %d of block %#x has invalid transaction index %d", l.Index, l.BlockHash, l.TxIndex)
+		}
+		if opts.Transaction {
+			result[i].Transaction = ethapi.NewRPCTransactionFromBlockIndex(block, uint64(l.TxIndex), config)
+		}
+		if opts.Receipt {
+			result[i].Receipt = newLogReceipt(receipts[l.TxIndex])
+		}
+	}
+	return result, nil
+}
+
+// newLogReceipt summarizes a receipt, reporting either the post-state root of
+// pre-Byzantium receipts or the status code.
+func newLogReceipt(receipt *types.Receipt) *LogReceipt {
+	summary := &LogReceipt{
+		GasUsed:           hexutil.Uint64(receipt.GasUsed),
+		CumulativeGasUsed: hexutil.Uint64(receipt.CumulativeGasUsed),
+	}
+	if len(receipt.PostState) > 0 {
+		summary.Root = receipt.PostState
+	} else {
+		status := hexutil.Uint64(receipt.Status)
+		summary.Status = &status
+	}
+	return summary
+}
+
 // UninstallFilter removes the filter with the given filter id.
 func (api *FilterAPI) UninstallFilter(id rpc.ID) bool {
 	api.filtersMu.Lock()
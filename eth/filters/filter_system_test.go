@@ -50,6 +50,9 @@ type testBackend struct {
 	rmLogsFeed      event.Feed
 	pendingLogsFeed event.Feed
 	chainFeed       event.Feed
+
+	pendingBlock    *types.Block
+	pendingReceipts types.Receipts
 }
 
 func (b *testBackend) ChainConfig() *params.ChainConfig {
@@ -122,7 +125,7 @@ func (b *testBackend) GetLogs(ctx context.Context, hash common.Hash, number uint
 }
 
 func (b *testBackend) PendingBlockAndReceipts() (*types.Block, types.Receipts) {
-	return nil, nil
+	return b.pendingBlock, b.pendingReceipts
 }
 
 func (b *testBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
@@ -241,6 +244,90 @@ func TestBlockSubscription(t *testing.T) {
 	<-sub1.Err()
 }
 
+// TestBlockWithReceiptsSubscription tests that new blocks are pushed to the
+// subscribers together with their transactions and receipts, and that blocks
+// which cannot be assembled are reported with an error.
+func TestBlockWithReceiptsSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys, false)
+		key, _       = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr         = crypto.PubkeyToAddress(key.PublicKey)
+		signer       = types.HomesteadSigner{}
+		genesis      = &core.Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   core.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	_, blocks, receipts := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 4, func(i int, b *core.BlockGen) {
+		for j := 0; j < i; j++ {
+			tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: b.TxNonce(addr), To: &common.Address{}, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: b.BaseFee()}), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	// The body and receipts of the last block are missing
+	for i, block := range blocks[:3] {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatalf("failed to register filter API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	results := make(chan *BlockWithReceipts)
+	sub, err := client.EthSubscribe(context.Background(), results, "newBlocksWithTxsAndReceipts")
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	time.Sleep(1 * time.Second)
+	for _, block := range blocks {
+		backend.chainFeed.Send(core.ChainEvent{Block: block, Hash: block.Hash()})
+	}
+	for i, block := range blocks {
+		select {
+		case res := <-results:
+			if hash := res.Block["hash"]; hash != block.Hash().Hex() {
+				t.Errorf("block %d: hash mismatch: have %v, want %v", i, hash, block.Hash())
+			}
+			if i == 3 {
+				if res.Error == "" || res.Receipts != nil {
+					t.Errorf("block %d: missing retrieval error: %v", i, res)
+				}
+				continue
+			}
+			if res.Error != "" {
+				t.Errorf("block %d: unexpected error: %v", i, res.Error)
+			}
+			if txs := res.Block["transactions"].([]interface{}); len(txs) != len(block.Transactions()) {
+				t.Errorf("block %d: transaction count mismatch: have %d, want %d", i, len(txs), len(block.Transactions()))
+			}
+			if len(res.Receipts) != len(receipts[i]) {
+				t.Fatalf("block %d: receipt count mismatch: have %d, want %d", i, len(res.Receipts), len(receipts[i]))
+			}
+			for j, receipt := range res.Receipts {
+				if have, want := receipt["transactionHash"], block.Transactions()[j].Hash().Hex(); have != want {
+					t.Errorf("block %d, receipt %d: transaction hash mismatch: have %v, want %v", i, j, have, want)
+				}
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("block %d: timeout waiting for notification", i)
+		}
+	}
+}
+
 // TestPendingTxFilter tests whether pending tx filters retrieve all pending transactions that are posted to the event mux.
 func TestPendingTxFilter(t *testing.T) {
 	t.Parallel()
@@ -561,6 +648,104 @@ func TestLogFilter(t *testing.T) {
 	}
 }
 
+// TestGetLogsWithContext tests that logs are returned along with their
+// transaction and receipt context.
+func TestGetLogsWithContext(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys, false)
+		key, _       = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr         = crypto.PubkeyToAddress(key.PublicKey)
+		emitter      = common.HexToAddress("0xe1")
+		signer       = types.HomesteadSigner{}
+		genesis      = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				addr:    {Balance: big.NewInt(params.Ether)},
+				emitter: {Balance: common.Big0, Code: common.FromHex("60006000a000")}, // LOG0 with empty data
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	_, blocks, receipts := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 4, func(i int, b *core.BlockGen) {
+		for j := 0; j < i; j++ {
+			tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: b.TxNonce(addr), To: &emitter, Gas: 50000, GasPrice: b.BaseFee()}), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	// The last block is pending, its logs don't carry a block hash yet
+	backend.pendingBlock, backend.pendingReceipts = blocks[3], receipts[3]
+	for _, receipt := range receipts[3] {
+		for _, l := range receipt.Logs {
+			l.BlockHash = common.Hash{}
+		}
+	}
+	blocks, receipts = blocks[:3], receipts[:3]
+	for i, block := range blocks {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	rawdb.WriteHeadBlockHash(db, blocks[len(blocks)-1].Hash())
+
+	crit := FilterCriteria{FromBlock: big.NewInt(1), ToBlock: big.NewInt(3), Addresses: []common.Address{emitter}}
+	logs, err := api.GetLogsWithContext(context.Background(), crit, nil)
+	if err != nil {
+		t.Fatalf("failed to get logs: %v", err)
+	}
+	if len(logs) != 3 {
+		t.Fatalf("log count mismatch: have %d, want %d", len(logs), 3)
+	}
+	for i, l := range logs {
+		block := blocks[l.Log.BlockNumber-1]
+		tx := block.Transactions()[l.Log.TxIndex]
+		if l.Transaction == nil || l.Transaction.Hash != tx.Hash() {
+			t.Errorf("log %d: transaction mismatch: have %v, want %v", i, l.Transaction, tx.Hash())
+		}
+		receipt := receipts[l.Log.BlockNumber-1][l.Log.TxIndex]
+		if l.Receipt == nil {
+			t.Fatalf("log %d: missing receipt", i)
+		}
+		if l.Receipt.Status == nil || uint64(*l.Receipt.Status) != types.ReceiptStatusSuccessful {
+			t.Errorf("log %d: status mismatch: have %v, want %d", i, l.Receipt.Status, types.ReceiptStatusSuccessful)
+		}
+		if uint64(l.Receipt.GasUsed) != receipt.GasUsed {
+			t.Errorf("log %d: gas used mismatch: have %d, want %d", i, l.Receipt.GasUsed, receipt.GasUsed)
+		}
+	}
+	// Context is only attached when requested
+	logs, err = api.GetLogsWithContext(context.Background(), crit, &LogContextOptions{Receipt: true})
+	if err != nil {
+		t.Fatalf("failed to get logs: %v", err)
+	}
+	for i, l := range logs {
+		if l.Transaction != nil || l.Receipt == nil {
+			t.Errorf("log %d: unexpected context: transaction %v, receipt %v", i, l.Transaction, l.Receipt)
+		}
+	}
+	// Pending logs are returned without context
+	crit.ToBlock = big.NewInt(rpc.PendingBlockNumber.Int64())
+	logs, err = api.GetLogsWithContext(context.Background(), crit, nil)
+	if err != nil {
+		t.Fatalf("failed to get pending logs: %v", err)
+	}
+	if len(logs) != 6 {
+		t.Fatalf("pending log count mismatch: have %d, want %d", len(logs), 6)
+	}
+	for i, l := range logs {
+		pending := l.Log.BlockHash == (common.Hash{})
+		if pending != (i >= 3) {
+			t.Errorf("log %d: pending mismatch: have %v, want %v", i, pending, i >= 3)
+		}
+		if have := l.Transaction != nil && l.Receipt != nil; have == pending {
+			t.Errorf("log %d: context mismatch: transaction %v, receipt %v", i, l.Transaction, l.Receipt)
+		}
+	}
+}
+
 // TestPendingLogsSubscription tests if a subscription receives the correct pending logs that are posted to the event feed.
 func TestPendingLogsSubscription(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,165 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package labels implements a persistent store of human readable address
+// labels, allowing hosted explorers to annotate responses without relying on
+// an external labelling service.
+package labels
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// maxNameLength is the maximum length in bytes of a label name.
+	maxNameLength = 256
+
+	// maxTags is the maximum number of tags attached to a single label.
+	maxTags = 32
+
+	// maxTagLength is the maximum length in bytes of a single tag.
+	maxTagLength = 64
+)
+
+var (
+	errEmptyName   = errors.New("label name is empty")
+	errNameTooLong = fmt.Errorf("label name exceeds %d bytes", maxNameLength)
+	errTooManyTags = fmt.Errorf("label has more than %d tags", maxTags)
+	errTagTooLong  = fmt.Errorf("label tag exceeds %d bytes", maxTagLength)
+)
+
+// Label is the human readable annotation attached to an address.
+type Label struct {
+	Name   string   `json:"name"`   // Display name of the address
+	Tags   []string `json:"tags"`   // Free form classification tags (e.g. "exchange", "bridge")
+	Source string   `json:"source"` // Origin of the label (e.g. "manual", "etherscan")
+}
+
+// HasTag reports whether the label carries the given tag.
+func (l *Label) HasTag(tag string) bool {
+	for _, t := range l.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks that the label is well formed and within the size limits.
+func (l *Label) validate() error {
+	if len(l.Name) == 0 {
+		return errEmptyName
+	}
+	if len(l.Name) > maxNameLength {
+		return errNameTooLong
+	}
+	if len(l.Tags) > maxTags {
+		return errTooManyTags
+	}
+	for _, tag := range l.Tags {
+		if len(tag) > maxTagLength {
+			return errTagTooLong
+		}
+	}
+	return nil
+}
+
+// Store is a database backed collection of address labels.
+type Store struct {
+	db ethdb.KeyValueStore
+}
+
+// NewStore creates a label store on top of the given database.
+func NewStore(db ethdb.KeyValueStore) *Store {
+	return &Store{db: db}
+}
+
+// Get retrieves the label of the given address, or nil if none is stored.
+func (s *Store) Get(address common.Address) *Label {
+	blob := rawdb.ReadAddressLabel(s.db, address)
+	if len(blob) == 0 {
+		return nil
+	}
+	label := new(Label)
+	if err := rlp.DecodeBytes(blob, label); err != nil {
+		log.Error("Invalid address label RLP", "address", address, "err", err)
+		return nil
+	}
+	return label
+}
+
+// GetMany retrieves the labels of the given addresses, omitting any that are
+// not labelled.
+func (s *Store) GetMany(addresses []common.Address) map[common.Address]*Label {
+	labels := make(map[common.Address]*Label)
+	for _, address := range addresses {
+		if _, ok := labels[address]; ok {
+			continue
+		}
+		if label := s.Get(address); label != nil {
+			labels[address] = label
+		}
+	}
+	return labels
+}
+
+// Put stores or replaces the label of the given address.
+func (s *Store) Put(address common.Address, label *Label) error {
+	if err := label.validate(); err != nil {
+		return err
+	}
+	blob, err := rlp.EncodeToBytes(label)
+	if err != nil {
+		return err
+	}
+	rawdb.WriteAddressLabel(s.db, address, blob)
+	return nil
+}
+
+// Delete removes the label of the given address, reporting whether there was
+// one to remove.
+func (s *Store) Delete(address common.Address) bool {
+	if len(rawdb.ReadAddressLabel(s.db, address)) == 0 {
+		return false
+	}
+	rawdb.DeleteAddressLabel(s.db, address)
+	return true
+}
+
+// List retrieves all stored labels. If tag is non-empty, only labels carrying
+// that tag are returned.
+func (s *Store) List(tag string) map[common.Address]*Label {
+	labels := make(map[common.Address]*Label)
+	for address, blob := range rawdb.ReadAllAddressLabels(s.db) {
+		label := new(Label)
+		if err := rlp.DecodeBytes(blob, label); err != nil {
+			log.Error("Invalid address label RLP", "address", address, "err", err)
+			continue
+		}
+		if tag != "" && !label.HasTag(tag) {
+			continue
+		}
+		labels[address] = label
+	}
+	return labels
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package labels
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestStoreCRUD(t *testing.T) {
+	var (
+		store = NewStore(rawdb.NewMemoryDatabase())
+		addr1 = common.Address{0x01}
+		addr2 = common.Address{0x02}
+	)
+	if label := store.Get(addr1); label != nil {
+		t.Fatalf("unexpected label before insertion: %v", label)
+	}
+	exchange := &Label{Name: "Exchange hot wallet", Tags: []string{"exchange", "cex"}, Source: "manual"}
+	if err := store.Put(addr1, exchange); err != nil {
+		t.Fatalf("failed to store label: %v", err)
+	}
+	bridge := &Label{Name: "Bridge", Tags: []string{"bridge"}, Source: "import"}
+	if err := store.Put(addr2, bridge); err != nil {
+		t.Fatalf("failed to store label: %v", err)
+	}
+	if have := store.Get(addr1); !reflect.DeepEqual(have, exchange) {
+		t.Fatalf("label mismatch: have %v, want %v", have, exchange)
+	}
+	if have := store.List(""); len(have) != 2 {
+		t.Fatalf("label count mismatch: have %d, want %d", len(have), 2)
+	}
+	if have := store.List("bridge"); len(have) != 1 || !reflect.DeepEqual(have[addr2], bridge) {
+		t.Fatalf("tag filtered label mismatch: have %v", have)
+	}
+	if have := store.GetMany([]common.Address{addr1, {0x03}, addr1}); len(have) != 1 {
+		t.Fatalf("batch lookup size mismatch: have %d, want %d", len(have), 1)
+	}
+	// Replace and delete the labels
+	renamed := &Label{Name: "Renamed", Tags: []string{}, Source: "manual"}
+	if err := store.Put(addr1, renamed); err != nil {
+		t.Fatalf("failed to replace label: %v", err)
+	}
+	if have := store.Get(addr1); !reflect.DeepEqual(have, renamed) {
+		t.Fatalf("replaced label mismatch: have %v, want %v", have, renamed)
+	}
+	if !store.Delete(addr1) {
+		t.Fatalf("failed to delete existing label")
+	}
+	if store.Delete(addr1) {
+		t.Fatalf("deleted missing label")
+	}
+	if have := store.List(""); len(have) != 1 {
+		t.Fatalf("label count mismatch after deletion: have %d, want %d", len(have), 1)
+	}
+}
+
+func TestStoreValidation(t *testing.T) {
+	store := NewStore(rawdb.NewMemoryDatabase())
+
+	tests := []struct {
+		label *Label
+		err   error
+	}{
+		{&Label{}, errEmptyName},
+		{&Label{Name: strings.Repeat("a", maxNameLength+1)}, errNameTooLong},
+		{&Label{Name: "a", Tags: make([]string, maxTags+1)}, errTooManyTags},
+		{&Label{Name: "a", Tags: []string{strings.Repeat("a", maxTagLength+1)}}, errTagTooLong},
+	}
+	for i, tt := range tests {
+		if err := store.Put(common.Address{}, tt.label); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
@@ -66,6 +66,10 @@ const (
 	// for tracing. The creation of trace state will be paused if the unused
 	// trace states exceed this limit.
 	maximumPendingTraceStates = 128
+
+	// maxTraceCalls is the maximum number of calls debug_traceCallMany traces
+	// in a single request.
+	maxTraceCalls = 256
 )
 
 var errTxNotFound = errors.New("transaction not found")
@@ -862,25 +866,43 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 	if blockNumber == 0 {
 		return nil, errors.New("genesis is not traceable")
 	}
-	reexec := defaultTraceReexec
-	if config != nil && config.Reexec != nil {
-		reexec = *config.Reexec
-	}
 	block, err := api.blockByNumberAndHash(ctx, rpc.BlockNumber(blockNumber), blockHash)
 	if err != nil {
 		return nil, err
 	}
-	msg, vmctx, statedb, release, err := api.backend.StateAtTransaction(ctx, block, int(index), reexec)
+	return api.traceBlockTx(ctx, block, int(index), config)
+}
+
+// TraceTransactionAt traces the transaction at the given index of the given
+// block, the same way debug_traceTransaction does. It allows other APIs to
+// trace a transaction of a block they already resolved.
+func TraceTransactionAt(ctx context.Context, backend Backend, block *types.Block, index int, config *TraceConfig) (interface{}, error) {
+	if block.NumberU64() == 0 {
+		return nil, errors.New("genesis is not traceable")
+	}
+	if index < 0 || index >= len(block.Transactions()) {
+		return nil, fmt.Errorf("transaction index %d out of range", index)
+	}
+	return NewAPI(backend).traceBlockTx(ctx, block, index, config)
+}
+
+// traceBlockTx traces the transaction at the given index of the given block.
+func (api *API) traceBlockTx(ctx context.Context, block *types.Block, index int, config *TraceConfig) (interface{}, error) {
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	msg, vmctx, statedb, release, err := api.backend.StateAtTransaction(ctx, block, index, reexec)
 	if err != nil {
 		return nil, err
 	}
 	defer release()
 
 	txctx := &Context{
-		BlockHash:   blockHash,
+		BlockHash:   block.Hash(),
 		BlockNumber: block.Number(),
-		TxIndex:     int(index),
-		TxHash:      hash,
+		TxIndex:     index,
+		TxHash:      block.Transactions()[index].Hash(),
 	}
 	return api.traceTx(ctx, msg, txctx, vmctx, statedb, config)
 }
@@ -890,25 +912,7 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 // top of the provided block and returns them as a JSON object.
 func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (interface{}, error) {
 	// Try to retrieve the specified block
-	var (
-		err   error
-		block *types.Block
-	)
-	if hash, ok := blockNrOrHash.Hash(); ok {
-		block, err = api.blockByHash(ctx, hash)
-	} else if number, ok := blockNrOrHash.Number(); ok {
-		if number == rpc.PendingBlockNumber {
-			// We don't have access to the miner here. For tracing 'future' transactions,
-			// it can be done with block- and state-overrides instead, which offers
-			// more flexibility and stability than trying to trace on 'pending', since
-			// the contents of 'pending' is unstable and probably not a true representation
-			// of what the next actual block is likely to contain.
-			return nil, errors.New("tracing on top of pending is not supported")
-		}
-		block, err = api.blockByNumber(ctx, number)
-	} else {
-		return nil, errors.New("invalid arguments; neither block nor hash specified")
-	}
+	block, err := api.callBlock(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -944,10 +948,124 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 	return api.traceTx(ctx, msg, new(Context), vmctx, statedb, traceConfig)
 }
 
+// callBlock retrieves the block on top of which calls are traced.
+func (api *API) callBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		return api.blockByHash(ctx, hash)
+	}
+	number, ok := blockNrOrHash.Number()
+	if !ok {
+		return nil, errors.New("invalid arguments; neither block nor hash specified")
+	}
+	if number == rpc.PendingBlockNumber {
+		// We don't have access to the miner here. For tracing 'future' transactions,
+		// it can be done with block- and state-overrides instead, which offers
+		// more flexibility and stability than trying to trace on 'pending', since
+		// the contents of 'pending' is unstable and probably not a true representation
+		// of what the next actual block is likely to contain.
+		return nil, errors.New("tracing on top of pending is not supported")
+	}
+	return api.blockByNumber(ctx, number)
+}
+
+// TraceCallMany lets you trace a sequence of calls on top of the given block,
+// each one executed on the state left behind by the previous ones. The state
+// and block overrides of the config are applied once, before the first call,
+// and the selected tracer is used for every call. The results are returned in
+// the order of the calls.
+//
+// The trace timeout and the RPC gas cap apply to the sequence as a whole
+// rather than to the individual calls.
+func (api *API) TraceCallMany(ctx context.Context, calls []ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) ([]interface{}, error) {
+	if len(calls) == 0 {
+		return nil, errors.New("empty call list")
+	}
+	if len(calls) > maxTraceCalls {
+		return nil, fmt.Errorf("too many calls: %d > %d", len(calls), maxTraceCalls)
+	}
+	block, err := api.callBlock(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	// try to recompute the state
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	statedb, release, err := api.backend.StateAtBlock(ctx, block, reexec, nil, true, false)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	vmctx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
+	// Apply the customization rules if required.
+	var traceConfig *TraceConfig
+	if config != nil {
+		if err := config.StateOverrides.Apply(statedb); err != nil {
+			return nil, err
+		}
+		config.BlockOverrides.Apply(&vmctx)
+		traceConfig = &config.TraceConfig
+	}
+	// The whole sequence shares a single deadline
+	timeout := defaultTraceTimeout
+	if traceConfig != nil && traceConfig.Timeout != nil {
+		if timeout, err = time.ParseDuration(*traceConfig.Timeout); err != nil {
+			return nil, err
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		is158     = api.backend.ChainConfig().IsEIP158(vmctx.BlockNumber)
+		gasCap    = api.backend.RPCGasCap()
+		remaining = gasCap
+		results   = make([]interface{}, len(calls))
+	)
+	for i, args := range calls {
+		// A cheap call may complete before its cancellation fires, so check the
+		// deadline in between them
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("call %d: %w", i, err)
+		}
+		if gasCap != 0 && remaining == 0 {
+			return nil, fmt.Errorf("call %d: gas cap of %d exhausted", i, gasCap)
+		}
+		msg, err := args.ToMessage(remaining, vmctx.BaseFee)
+		if err != nil {
+			return nil, fmt.Errorf("call %d: %w", i, err)
+		}
+		txctx := &Context{
+			BlockNumber: vmctx.BlockNumber,
+			TxIndex:     i,
+		}
+		res, result, err := api.traceMessage(ctx, msg, txctx, vmctx, statedb, traceConfig)
+		if err != nil {
+			return nil, fmt.Errorf("call %d: %w", i, err)
+		}
+		results[i] = res
+		if gasCap != 0 {
+			remaining -= result.UsedGas
+		}
+		// Finalize the state so the next call sees the modifications
+		statedb.Finalise(is158)
+	}
+	return results, nil
+}
+
 // traceTx configures a new tracer according to the provided configuration, and
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
 func (api *API) traceTx(ctx context.Context, message *core.Message, txctx *Context, vmctx vm.BlockContext, statedb *state.StateDB, config *TraceConfig) (interface{}, error) {
+	res, _, err := api.traceMessage(ctx, message, txctx, vmctx, statedb, config)
+	return res, err
+}
+
+// traceMessage is traceTx, additionally returning the execution result of the
+// message.
+func (api *API) traceMessage(ctx context.Context, message *core.Message, txctx *Context, vmctx vm.BlockContext, statedb *state.StateDB, config *TraceConfig) (interface{}, *core.ExecutionResult, error) {
 	var (
 		tracer    Tracer
 		err       error
@@ -962,7 +1080,7 @@ func (api *API) traceTx(ctx context.Context, message *core.Message, txctx *Conte
 	if config.Tracer != nil {
 		tracer, err = DefaultDirectory.New(*config.Tracer, txctx, config.TracerConfig)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 	vmenv := vm.NewEVM(vmctx, txContext, statedb, api.backend.ChainConfig(), vm.Config{Debug: true, Tracer: tracer, NoBaseFee: true})
@@ -970,7 +1088,7 @@ func (api *API) traceTx(ctx context.Context, message *core.Message, txctx *Conte
 	// Define a meaningful timeout of a single transaction trace
 	if config.Timeout != nil {
 		if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 	deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
@@ -986,10 +1104,12 @@ func (api *API) traceTx(ctx context.Context, message *core.Message, txctx *Conte
 
 	// Call Prepare to clear out the statedb access list
 	statedb.SetTxContext(txctx.TxHash, txctx.TxIndex)
-	if _, err = core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.GasLimit)); err != nil {
-		return nil, fmt.Errorf("tracing failed: %w", err)
+	result, err := core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.GasLimit))
+	if err != nil {
+		return nil, nil, fmt.Errorf("tracing failed: %w", err)
 	}
-	return tracer.GetResult()
+	res, err := tracer.GetResult()
+	return res, result, err
 }
 
 // APIs return the collection of RPC services the tracer package offers.
@@ -1000,6 +1120,10 @@ func APIs(backend Backend) []rpc.API {
 			Namespace: "debug",
 			Service:   NewAPI(backend),
 		},
+		{
+			Namespace: "trace",
+			Service:   NewTraceAPI(backend),
+		},
 	}
 }
 
@@ -26,6 +26,7 @@ import (
 	"math/big"
 	"reflect"
 	"sort"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -321,6 +322,88 @@ func TestTraceCall(t *testing.T) {
 	}
 }
 
+func TestTraceCallMany(t *testing.T) {
+	t.Parallel()
+
+	// Initialize test accounts
+	accounts := newAccounts(3)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			accounts[1].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {})
+	defer backend.teardown()
+	api := NewAPI(backend)
+
+	var (
+		latest   = rpc.BlockNumberOrHash{BlockNumber: new(rpc.BlockNumber)}
+		transfer = `{"gas":21000,"failed":false,"returnValue":"","structLogs":[]}`
+		// Funding account[2] only succeeds if it sees the state of the first call
+		calls = []ethapi.TransactionArgs{
+			{From: &accounts[0].addr, To: &accounts[2].addr, Value: (*hexutil.Big)(big.NewInt(params.GWei))},
+			{From: &accounts[2].addr, To: &accounts[1].addr, Value: (*hexutil.Big)(big.NewInt(1000))},
+		}
+	)
+	*latest.BlockNumber = rpc.LatestBlockNumber
+
+	results, err := api.TraceCallMany(context.Background(), calls, latest, nil)
+	if err != nil {
+		t.Fatalf("failed to trace calls: %v", err)
+	}
+	if len(results) != len(calls) {
+		t.Fatalf("result count mismatch: have %d, want %d", len(results), len(calls))
+	}
+	for i, result := range results {
+		if have := string(result.(json.RawMessage)); have != transfer {
+			t.Errorf("call %d: result mismatch: have %s, want %s", i, have, transfer)
+		}
+	}
+	// Without the first call, the second one can't pay for the transfer
+	if _, err := api.TraceCallMany(context.Background(), calls[1:], latest, nil); err == nil || !strings.HasPrefix(err.Error(), "call 0:") {
+		t.Errorf("expected error for the unfunded call, got %v", err)
+	}
+	// Unless the state overrides fund the account upfront
+	config := &TraceCallConfig{
+		StateOverrides: &ethapi.StateOverride{accounts[2].addr: ethapi.OverrideAccount{Balance: newRPCBalance(big.NewInt(params.GWei))}},
+	}
+	results, err = api.TraceCallMany(context.Background(), calls[1:], latest, config)
+	if err != nil {
+		t.Fatalf("failed to trace calls with overrides: %v", err)
+	}
+	if have := string(results[0].(json.RawMessage)); have != transfer {
+		t.Errorf("result mismatch: have %s, want %s", have, transfer)
+	}
+	// The number of calls is limited
+	if _, err := api.TraceCallMany(context.Background(), make([]ethapi.TransactionArgs, maxTraceCalls+1), latest, nil); err == nil || !strings.Contains(err.Error(), "too many calls") {
+		t.Errorf("expected error for too many calls, got %v", err)
+	}
+	// The gas cap is shared by the sequence, a call burning all its gas
+	// exhausts it for the next ones
+	burner := common.HexToAddress("0xfe")
+	config = &TraceCallConfig{
+		StateOverrides: &ethapi.StateOverride{burner: ethapi.OverrideAccount{Code: newRPCBytes([]byte{byte(vm.INVALID)})}},
+	}
+	burn := []ethapi.TransactionArgs{{From: &accounts[0].addr, To: &burner}, {From: &accounts[0].addr, To: &burner}}
+	if _, err := api.TraceCallMany(context.Background(), burn, latest, config); err == nil || !strings.HasPrefix(err.Error(), "call 1: gas cap") {
+		t.Errorf("expected gas cap exhaustion, got %v", err)
+	}
+	// The timeout is shared by the sequence, no call is traced past it
+	timeout := "1ns"
+	config = &TraceCallConfig{TraceConfig: TraceConfig{Timeout: &timeout}}
+	many := make([]ethapi.TransactionArgs, maxTraceCalls)
+	for i := range many {
+		many[i] = ethapi.TransactionArgs{From: &accounts[0].addr, To: &accounts[1].addr}
+	}
+	// The deadline is either caught in between calls or aborts a call midway
+	_, err = api.TraceCallMany(context.Background(), many, latest, config)
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) && !strings.HasSuffix(err.Error(), "execution timeout") {
+		t.Errorf("expected deadline error, got %v", err)
+	}
+}
+
 func TestTraceTransaction(t *testing.T) {
 	t.Parallel()
 
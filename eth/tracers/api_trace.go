@@ -0,0 +1,310 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// flatTracer is the native tracer producing Parity-style flat traces.
+	flatTracer = "flatCallTracer"
+
+	// maxTraceFilterBlocks is the maximum number of blocks trace_filter traces
+	// in a single request.
+	maxTraceFilterBlocks = 100
+)
+
+// TraceAPI is the collection of Parity-style tracing APIs exposed over the
+// trace namespace. The traces are produced by the native flat call tracer,
+// block rewards are not reported.
+type TraceAPI struct {
+	api *API
+}
+
+// NewTraceAPI creates a new API definition for the Parity-style tracing
+// methods of the Ethereum service.
+func NewTraceAPI(backend Backend) *TraceAPI {
+	return &TraceAPI{api: NewAPI(backend)}
+}
+
+// flatTraceConfig returns the config tracing with the flat call tracer,
+// reporting errors the way Parity did.
+func flatTraceConfig() *TraceConfig {
+	tracer := flatTracer
+	return &TraceConfig{
+		Tracer:       &tracer,
+		TracerConfig: json.RawMessage(`{"convertParityErrors":true}`),
+	}
+}
+
+// splitTraces splits the result of the flat call tracer into its traces.
+func splitTraces(result interface{}) ([]json.RawMessage, error) {
+	blob, ok := result.(json.RawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected trace result type %T", result)
+	}
+	traces := make([]json.RawMessage, 0)
+	if err := json.Unmarshal(blob, &traces); err != nil {
+		return nil, err
+	}
+	return traces, nil
+}
+
+// Block returns the flat traces of all the transactions in the given block.
+func (api *TraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]json.RawMessage, error) {
+	block, err := api.api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return api.blockTraces(ctx, block)
+}
+
+// blockTraces traces all the transactions in the given block, concatenating
+// their flat traces.
+func (api *TraceAPI) blockTraces(ctx context.Context, block *types.Block) ([]json.RawMessage, error) {
+	traces := make([]json.RawMessage, 0)
+	if block.NumberU64() == 0 {
+		return traces, nil
+	}
+	results, err := api.api.traceBlock(ctx, block, flatTraceConfig())
+	if err != nil {
+		return nil, err
+	}
+	for i, res := range results {
+		if res.Error != "" {
+			return nil, fmt.Errorf("transaction %d: %s", i, res.Error)
+		}
+		txTraces, err := splitTraces(res.Result)
+		if err != nil {
+			return nil, err
+		}
+		traces = append(traces, txTraces...)
+	}
+	return traces, nil
+}
+
+// Transaction returns the flat traces of the transaction with the given hash.
+func (api *TraceAPI) Transaction(ctx context.Context, hash common.Hash) ([]json.RawMessage, error) {
+	result, err := api.api.TraceTransaction(ctx, hash, flatTraceConfig())
+	if err != nil {
+		return nil, err
+	}
+	return splitTraces(result)
+}
+
+// TraceResults is the result of replaying a transaction. Only the trace type
+// is supported, the state and VM trace are always empty.
+type TraceResults struct {
+	Output    hexutil.Bytes     `json:"output"`
+	StateDiff json.RawMessage   `json:"stateDiff"`
+	Trace     []json.RawMessage `json:"trace"`
+	VmTrace   json.RawMessage   `json:"vmTrace"`
+}
+
+// ReplayTransaction replays the transaction with the given hash, returning its
+// output along with the requested trace types.
+func (api *TraceAPI) ReplayTransaction(ctx context.Context, hash common.Hash, traceTypes []string) (*TraceResults, error) {
+	var withTrace bool
+	for _, typ := range traceTypes {
+		if typ != "trace" {
+			return nil, fmt.Errorf("unsupported trace type %q", typ)
+		}
+		withTrace = true
+	}
+	traces, err := api.Transaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	results := &TraceResults{Trace: make([]json.RawMessage, 0)}
+	if withTrace {
+		results.Trace = traces
+	}
+	if len(traces) > 0 {
+		// The output of the transaction is the one of the top level call
+		var top struct {
+			Result *struct {
+				Code   hexutil.Bytes `json:"code"`
+				Output hexutil.Bytes `json:"output"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(traces[0], &top); err != nil {
+			return nil, err
+		}
+		if top.Result != nil {
+			results.Output = top.Result.Output
+			if top.Result.Code != nil {
+				results.Output = top.Result.Code
+			}
+		}
+	}
+	return results, nil
+}
+
+// TraceFilterArgs are the arguments of trace_filter.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *uint64          `json:"after"`
+	Count       *uint64          `json:"count"`
+}
+
+// traceFilter matches flat traces by their sender and recipient. An empty
+// address set matches any address.
+type traceFilter struct {
+	from map[common.Address]struct{}
+	to   map[common.Address]struct{}
+}
+
+func newTraceFilter(from, to []common.Address) *traceFilter {
+	f := &traceFilter{
+		from: make(map[common.Address]struct{}),
+		to:   make(map[common.Address]struct{}),
+	}
+	for _, addr := range from {
+		f.from[addr] = struct{}{}
+	}
+	for _, addr := range to {
+		f.to[addr] = struct{}{}
+	}
+	return f
+}
+
+// matches reports whether the given flat trace is sent from one of the
+// filtered senders to one of the filtered recipients. The recipient of a
+// creation is the created contract and the one of a selfdestruct is the
+// refund address.
+func (f *traceFilter) matches(trace json.RawMessage) (bool, error) {
+	var dec struct {
+		Action struct {
+			From          *common.Address `json:"from"`
+			To            *common.Address `json:"to"`
+			Address       *common.Address `json:"address"`
+			RefundAddress *common.Address `json:"refundAddress"`
+		} `json:"action"`
+		Result *struct {
+			Address *common.Address `json:"address"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(trace, &dec); err != nil {
+		return false, err
+	}
+	from, to := dec.Action.From, dec.Action.To
+	if from == nil {
+		from = dec.Action.Address
+	}
+	if to == nil {
+		to = dec.Action.RefundAddress
+	}
+	if to == nil && dec.Result != nil {
+		to = dec.Result.Address
+	}
+	return matchesAddress(f.from, from) && matchesAddress(f.to, to), nil
+}
+
+func matchesAddress(set map[common.Address]struct{}, addr *common.Address) bool {
+	if len(set) == 0 {
+		return true
+	}
+	if addr == nil {
+		return false
+	}
+	_, ok := set[*addr]
+	return ok
+}
+
+// Filter returns the flat traces in the given block range matching the given
+// sender and recipient addresses. The matching traces can be paginated with
+// after and count.
+func (api *TraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]json.RawMessage, error) {
+	var (
+		begin = rpc.LatestBlockNumber
+		end   = rpc.LatestBlockNumber
+	)
+	if args.FromBlock != nil {
+		begin = *args.FromBlock
+	}
+	if args.ToBlock != nil {
+		end = *args.ToBlock
+	}
+	first, err := api.api.blockByNumber(ctx, begin)
+	if err != nil {
+		return nil, err
+	}
+	last, err := api.api.blockByNumber(ctx, end)
+	if err != nil {
+		return nil, err
+	}
+	from, to := first.NumberU64(), last.NumberU64()
+	if from > to {
+		return nil, fmt.Errorf("invalid block range: %d > %d", from, to)
+	}
+	if to-from >= maxTraceFilterBlocks {
+		return nil, fmt.Errorf("block range too large: %d blocks, limit %d", to-from+1, maxTraceFilterBlocks)
+	}
+	var (
+		filter  = newTraceFilter(args.FromAddress, args.ToAddress)
+		skip    uint64
+		matched = make([]json.RawMessage, 0)
+	)
+	if args.After != nil {
+		skip = *args.After
+	}
+	if args.Count != nil && *args.Count == 0 {
+		return matched, nil
+	}
+	for number := from; number <= to; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block, err := api.api.blockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		traces, err := api.blockTraces(ctx, block)
+		if err != nil {
+			return nil, err
+		}
+		for _, trace := range traces {
+			ok, err := filter.matches(trace)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			matched = append(matched, trace)
+			if args.Count != nil && uint64(len(matched)) >= *args.Count {
+				return matched, nil
+			}
+		}
+	}
+	return matched, nil
+}
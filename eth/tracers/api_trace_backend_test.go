@@ -0,0 +1,258 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	traceKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	traceSender = crypto.PubkeyToAddress(traceKey.PublicKey)
+	traceCaller = common.HexToAddress("0xaaaa")
+	traceCallee = common.HexToAddress("0xbbbb")
+	traceWord   = common.LeftPadBytes([]byte{0x2a}, 32)
+
+	// Deploys the single byte runtime code 0xfe
+	traceInitCode = common.FromHex("6001600c60003960016000f3fe")
+)
+
+// flatTrace holds the flat trace fields checked by the tests.
+type flatTrace struct {
+	Type   string `json:"type"`
+	Action struct {
+		From *common.Address `json:"from"`
+		To   *common.Address `json:"to"`
+	} `json:"action"`
+	Result *struct {
+		Address *common.Address `json:"address"`
+		Code    hexutil.Bytes   `json:"code"`
+		Output  hexutil.Bytes   `json:"output"`
+	} `json:"result"`
+	TraceAddress        []int       `json:"traceAddress"`
+	TransactionHash     common.Hash `json:"transactionHash"`
+	TransactionPosition uint64      `json:"transactionPosition"`
+	BlockNumber         uint64      `json:"blockNumber"`
+}
+
+func decodeTraces(t *testing.T, traces []json.RawMessage) []flatTrace {
+	t.Helper()
+
+	decoded := make([]flatTrace, len(traces))
+	for i, trace := range traces {
+		if err := json.Unmarshal(trace, &decoded[i]); err != nil {
+			t.Fatalf("trace %d: failed to decode: %v", i, err)
+		}
+	}
+	return decoded
+}
+
+// newTraceAPI creates a chain of the given length whose first block calls a
+// contract calling another one and calls the latter directly, and whose second
+// block deploys a contract.
+func newTraceAPI(t *testing.T, n int) (*tracers.TraceAPI, []common.Hash) {
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			traceSender: {Balance: big.NewInt(params.Ether)},
+			// Calls the callee
+			traceCaller: {Balance: common.Big0, Code: common.FromHex("6000600060006000600061bbbb5af100")},
+			// Returns the word 0x2a
+			traceCallee: {Balance: common.Big0, Code: common.FromHex("602a60005260206000f3")},
+		},
+	}
+	var (
+		signer = types.HomesteadSigner{}
+		hashes []common.Hash
+	)
+	backend := tracers.NewTestBackend(t, n, genesis, func(i int, b *core.BlockGen) {
+		var txs []*types.Transaction
+		switch i {
+		case 0:
+			txs = append(txs,
+				types.NewTransaction(b.TxNonce(traceSender), traceCaller, common.Big0, 100_000, b.BaseFee(), nil),
+				types.NewTransaction(b.TxNonce(traceSender)+1, traceCallee, common.Big0, 100_000, b.BaseFee(), nil),
+			)
+		case 1:
+			txs = append(txs, types.NewContractCreation(b.TxNonce(traceSender), common.Big0, 100_000, b.BaseFee(), traceInitCode))
+		}
+		for _, tx := range txs {
+			tx, _ = types.SignTx(tx, signer, traceKey)
+			b.AddTx(tx)
+			hashes = append(hashes, tx.Hash())
+		}
+	})
+	return tracers.NewTraceAPI(backend), hashes
+}
+
+func TestTraceAPIBlockAndTransaction(t *testing.T) {
+	t.Parallel()
+
+	api, hashes := newTraceAPI(t, 2)
+
+	// The block traces are the traces of its transactions, in order
+	traces, err := api.Block(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	decoded := decodeTraces(t, traces)
+	want := []struct {
+		to           common.Address
+		traceAddress []int
+		tx           int
+	}{
+		{traceCaller, []int{}, 0},
+		{traceCallee, []int{0}, 0},
+		{traceCallee, []int{}, 1},
+	}
+	if len(decoded) != len(want) {
+		t.Fatalf("trace count mismatch: have %d, want %d", len(decoded), len(want))
+	}
+	for i, trace := range decoded {
+		if trace.Action.To == nil || *trace.Action.To != want[i].to {
+			t.Errorf("trace %d: recipient mismatch: have %v, want %x", i, trace.Action.To, want[i].to)
+		}
+		if !reflect.DeepEqual(trace.TraceAddress, want[i].traceAddress) {
+			t.Errorf("trace %d: trace address mismatch: have %v, want %v", i, trace.TraceAddress, want[i].traceAddress)
+		}
+		if trace.TransactionHash != hashes[want[i].tx] || trace.TransactionPosition != uint64(want[i].tx) {
+			t.Errorf("trace %d: transaction mismatch: have %x at %d, want %x at %d", i, trace.TransactionHash, trace.TransactionPosition, hashes[want[i].tx], want[i].tx)
+		}
+		if trace.BlockNumber != 1 {
+			t.Errorf("trace %d: block number mismatch: have %d, want 1", i, trace.BlockNumber)
+		}
+	}
+	// The transaction traces are the block traces of the transaction
+	traces, err = api.Transaction(context.Background(), hashes[0])
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	if have := decodeTraces(t, traces); !reflect.DeepEqual(have, decoded[:2]) {
+		t.Errorf("transaction traces mismatch: have %+v, want %+v", have, decoded[:2])
+	}
+	// The genesis block has no traces
+	traces, err = api.Block(context.Background(), 0)
+	if err != nil || len(traces) != 0 {
+		t.Errorf("genesis traces mismatch: have %v, %v, want none", traces, err)
+	}
+}
+
+func TestTraceAPIReplayTransaction(t *testing.T) {
+	t.Parallel()
+
+	api, hashes := newTraceAPI(t, 2)
+
+	// The output of a call is its return data
+	res, err := api.ReplayTransaction(context.Background(), hashes[1], []string{"trace"})
+	if err != nil {
+		t.Fatalf("failed to replay call: %v", err)
+	}
+	if !bytes.Equal(res.Output, traceWord) {
+		t.Errorf("call output mismatch: have %x, want %x", res.Output, traceWord)
+	}
+	if len(res.Trace) != 1 {
+		t.Errorf("call trace count mismatch: have %d, want 1", len(res.Trace))
+	}
+	// The output of a creation is the deployed code
+	res, err = api.ReplayTransaction(context.Background(), hashes[2], []string{"trace"})
+	if err != nil {
+		t.Fatalf("failed to replay creation: %v", err)
+	}
+	if want := []byte{0xfe}; !bytes.Equal(res.Output, want) {
+		t.Errorf("creation output mismatch: have %x, want %x", res.Output, want)
+	}
+	created := crypto.CreateAddress(traceSender, 2)
+	if trace := decodeTraces(t, res.Trace); len(trace) != 1 || trace[0].Type != "create" || trace[0].Result == nil || *trace[0].Result.Address != created {
+		t.Errorf("creation trace mismatch: have %+v, want create of %x", trace, created)
+	}
+	// The traces are only reported if requested
+	res, err = api.ReplayTransaction(context.Background(), hashes[1], nil)
+	if err != nil {
+		t.Fatalf("failed to replay call: %v", err)
+	}
+	if len(res.Trace) != 0 || !bytes.Equal(res.Output, traceWord) {
+		t.Errorf("untraced replay mismatch: have %d traces, output %x", len(res.Trace), res.Output)
+	}
+	// The state diff and VM trace are not supported
+	for _, typ := range []string{"stateDiff", "vmTrace"} {
+		if _, err := api.ReplayTransaction(context.Background(), hashes[1], []string{"trace", typ}); err == nil {
+			t.Errorf("trace type %s: expected error", typ)
+		}
+	}
+}
+
+func TestTraceAPIFilter(t *testing.T) {
+	t.Parallel()
+
+	api, _ := newTraceAPI(t, tracers.MaxTraceFilterBlocks+1)
+
+	var (
+		first  = rpc.BlockNumber(1)
+		second = rpc.BlockNumber(2)
+		last   = rpc.BlockNumber(tracers.MaxTraceFilterBlocks)
+	)
+	all, err := api.Filter(context.Background(), tracers.TraceFilterArgs{FromBlock: &first, ToBlock: &second})
+	if err != nil {
+		t.Fatalf("failed to filter traces: %v", err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("trace count mismatch: have %d, want 4", len(all))
+	}
+	// The matching traces are paginated with after and count
+	var (
+		after = uint64(1)
+		count = uint64(2)
+	)
+	page, err := api.Filter(context.Background(), tracers.TraceFilterArgs{FromBlock: &first, ToBlock: &second, After: &after, Count: &count})
+	if err != nil {
+		t.Fatalf("failed to filter traces: %v", err)
+	}
+	if have, want := decodeTraces(t, page), decodeTraces(t, all[1:3]); !reflect.DeepEqual(have, want) {
+		t.Errorf("page mismatch: have %+v, want %+v", have, want)
+	}
+	// Pagination applies to the traces matching the addresses
+	page, err = api.Filter(context.Background(), tracers.TraceFilterArgs{FromBlock: &first, ToBlock: &second, ToAddress: []common.Address{traceCallee}, After: &after})
+	if err != nil {
+		t.Fatalf("failed to filter traces: %v", err)
+	}
+	if have, want := decodeTraces(t, page), decodeTraces(t, all[2:3]); !reflect.DeepEqual(have, want) {
+		t.Errorf("filtered page mismatch: have %+v, want %+v", have, want)
+	}
+	// The range is limited to maxTraceFilterBlocks blocks
+	if _, err := api.Filter(context.Background(), tracers.TraceFilterArgs{FromBlock: &first, ToBlock: &last}); err != nil {
+		t.Errorf("failed to filter %d blocks: %v", tracers.MaxTraceFilterBlocks, err)
+	}
+	genesis := rpc.BlockNumber(0)
+	if _, err := api.Filter(context.Background(), tracers.TraceFilterArgs{FromBlock: &genesis, ToBlock: &last}); err == nil {
+		t.Errorf("expected error filtering %d blocks", tracers.MaxTraceFilterBlocks+1)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestTraceFilterMatches(t *testing.T) {
+	var (
+		a = common.HexToAddress("0xaa")
+		b = common.HexToAddress("0xbb")
+		c = common.HexToAddress("0xcc")
+
+		call     = json.RawMessage(`{"action":{"callType":"call","from":"0x00000000000000000000000000000000000000aa","to":"0x00000000000000000000000000000000000000bb"},"type":"call"}`)
+		create   = json.RawMessage(`{"action":{"from":"0x00000000000000000000000000000000000000aa"},"result":{"address":"0x00000000000000000000000000000000000000cc"},"type":"create"}`)
+		suicide  = json.RawMessage(`{"action":{"address":"0x00000000000000000000000000000000000000cc","refundAddress":"0x00000000000000000000000000000000000000bb"},"type":"suicide"}`)
+		reverted = json.RawMessage(`{"action":{"from":"0x00000000000000000000000000000000000000aa"},"error":"Reverted","type":"create"}`)
+	)
+	tests := []struct {
+		from, to []common.Address
+		trace    json.RawMessage
+		want     bool
+	}{
+		{nil, nil, call, true},
+		{[]common.Address{a}, nil, call, true},
+		{[]common.Address{b}, nil, call, false},
+		{nil, []common.Address{b}, call, true},
+		{[]common.Address{a}, []common.Address{c}, call, false},
+		{[]common.Address{a}, []common.Address{c}, create, true},
+		{[]common.Address{c}, []common.Address{b}, suicide, true},
+		{nil, []common.Address{c}, reverted, false},
+		{[]common.Address{b, a}, nil, reverted, true},
+	}
+	for i, tt := range tests {
+		have, err := newTraceFilter(tt.from, tt.to).matches(tt.trace)
+		if err != nil {
+			t.Fatalf("test %d: failed to match trace: %v", i, err)
+		}
+		if have != tt.want {
+			t.Errorf("test %d: match mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
@@ -127,6 +127,9 @@ func TestGethClient(t *testing.T) {
 		}, {
 			"TestCallContract",
 			func(t *testing.T) { testCallContract(t, client) },
+		}, {
+			"TestCallContractWithBlockOverrides",
+			func(t *testing.T) { testCallContractWithBlockOverrides(t, client) },
 		},
 		// The testaccesslist is a bit time-sensitive: the newTestBackend imports
 		// one block. The `testAcessList` fails if the miner has not yet created a
@@ -364,6 +367,89 @@ func testCallContract(t *testing.T, client *rpc.Client) {
 	}
 }
 
+func testCallContractWithBlockOverrides(t *testing.T, client *rpc.Client) {
+	ec := New(client)
+
+	// NUMBER, TIMESTAMP and COINBASE stored into consecutive memory words and returned
+	contract := common.Address{0xc0, 0xde}
+	code := common.FromHex("0x436000524260205241604052606060" + "00f3")
+	msg := ethereum.CallMsg{
+		From:     testAddr,
+		To:       &contract,
+		Gas:      50000,
+		GasPrice: big.NewInt(1000000000),
+	}
+	overrides := map[common.Address]OverrideAccount{
+		contract: {Code: code},
+	}
+	// Without block overrides, the context of the requested block is used
+	res, err := ec.CallContractWithBlockOverrides(context.Background(), msg, big.NewInt(0), &overrides, BlockOverrides{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res) != 96 {
+		t.Fatalf("unexpected result length: have %d, want %d", len(res), 96)
+	}
+	if number := new(big.Int).SetBytes(res[:32]); number.Sign() != 0 {
+		t.Fatalf("unexpected block number: have %v, want 0", number)
+	}
+	// With block overrides, the overridden context is visible to the contract
+	blockOverrides := BlockOverrides{
+		Number:   big.NewInt(0x1234),
+		Time:     0x5678,
+		Coinbase: common.Address{0xbe, 0xef},
+	}
+	res, err = ec.CallContractWithBlockOverrides(context.Background(), msg, big.NewInt(0), &overrides, blockOverrides)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if number := new(big.Int).SetBytes(res[:32]); number.Cmp(blockOverrides.Number) != 0 {
+		t.Errorf("block number override mismatch: have %v, want %v", number, blockOverrides.Number)
+	}
+	if time := new(big.Int).SetBytes(res[32:64]); time.Uint64() != blockOverrides.Time {
+		t.Errorf("timestamp override mismatch: have %v, want %v", time, blockOverrides.Time)
+	}
+	if coinbase := common.BytesToAddress(res[64:]); coinbase != blockOverrides.Coinbase {
+		t.Errorf("coinbase override mismatch: have %v, want %v", coinbase, blockOverrides.Coinbase)
+	}
+}
+
+func TestBlockOverridesMarshal(t *testing.T) {
+	for i, tt := range []struct {
+		bo   BlockOverrides
+		want string
+	}{
+		{
+			bo:   BlockOverrides{},
+			want: `{}`,
+		},
+		{
+			bo: BlockOverrides{
+				Coinbase: common.HexToAddress("0x1111111111111111111111111111111111111111"),
+			},
+			want: `{"coinbase":"0x1111111111111111111111111111111111111111"}`,
+		},
+		{
+			bo: BlockOverrides{
+				Number:     big.NewInt(1),
+				Difficulty: big.NewInt(2),
+				Time:       3,
+				GasLimit:   4,
+				BaseFee:    big.NewInt(5),
+			},
+			want: `{"number":"0x1","difficulty":"0x2","time":"0x3","gasLimit":"0x4","baseFee":"0x5"}`,
+		},
+	} {
+		marshalled, err := json.Marshal(&tt.bo)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(marshalled) != tt.want {
+			t.Errorf("Testcase #%d failed. expected\n%s\ngot\n%s", i, tt.want, marshalled)
+		}
+	}
+}
+
 func TestOverrideAccountMarshal(t *testing.T) {
 	om := map[common.Address]OverrideAccount{
 		common.Address{0x11}: OverrideAccount{
@@ -17,14 +17,32 @@
 package ethapi
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"math/big"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 func TestTransaction_RoundTripRpcJSON(t *testing.T) {
@@ -157,3 +175,313 @@ func allTransactionTypes(addr common.Address, config *params.ChainConfig) []type
 		},
 	}
 }
+
+// testBackend is a Backend serving a local test chain, optionally along with
+// pending pool transactions.
+type testBackend struct {
+	db      ethdb.Database
+	chain   *core.BlockChain
+	pending map[common.Hash]*types.Transaction
+	gasCap  uint64
+	timeout time.Duration
+}
+
+func newTestBackend(t *testing.T, n int, gspec *core.Genesis, generator func(i int, b *core.BlockGen)) *testBackend {
+	var (
+		engine      = ethash.NewFaker()
+		db          = rawdb.NewMemoryDatabase()
+		cacheConfig = &core.CacheConfig{
+			TrieCleanLimit:    256,
+			TrieDirtyLimit:    256,
+			TrieTimeLimit:     5 * time.Minute,
+			SnapshotLimit:     0,
+			TrieDirtyDisabled: true, // Archive mode
+		}
+	)
+	// Generate blocks for testing
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, engine, n, generator)
+
+	// Import the canonical chain
+	chain, err := core.NewBlockChain(db, cacheConfig, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	t.Cleanup(chain.Stop)
+	return &testBackend{
+		db:      db,
+		chain:   chain,
+		pending: make(map[common.Hash]*types.Transaction),
+		gasCap:  50_000_000,
+		timeout: 5 * time.Second,
+	}
+}
+
+func (b *testBackend) SyncProgress() ethereum.SyncProgress { return ethereum.SyncProgress{} }
+func (b *testBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+func (b *testBackend) FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
+	return nil, nil, nil, nil, nil
+}
+func (b *testBackend) ChainDb() ethdb.Database           { return b.db }
+func (b *testBackend) AccountManager() *accounts.Manager { return nil }
+func (b *testBackend) ExtRPCEnabled() bool               { return false }
+func (b *testBackend) RPCGasCap() uint64                 { return b.gasCap }
+func (b *testBackend) RPCEVMTimeout() time.Duration      { return b.timeout }
+func (b *testBackend) RPCTxFeeCap() float64              { return 0 }
+func (b *testBackend) UnprotectedAllowed() bool          { return false }
+func (b *testBackend) SetHead(number uint64)             {}
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if number == rpc.LatestBlockNumber {
+		return b.chain.CurrentBlock(), nil
+	}
+	if number < 0 {
+		return nil, errors.New("unsupported block number")
+	}
+	return b.chain.GetHeaderByNumber(uint64(number)), nil
+}
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return b.chain.GetHeaderByHash(hash), nil
+}
+func (b *testBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	if number, ok := blockNrOrHash.Number(); ok {
+		return b.HeaderByNumber(ctx, number)
+	}
+	hash, _ := blockNrOrHash.Hash()
+	return b.HeaderByHash(ctx, hash)
+}
+func (b *testBackend) CurrentHeader() *types.Header { return b.chain.CurrentBlock() }
+func (b *testBackend) CurrentBlock() *types.Header  { return b.chain.CurrentBlock() }
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	header, err := b.HeaderByNumber(ctx, number)
+	if header == nil || err != nil {
+		return nil, err
+	}
+	return b.chain.GetBlock(header.Hash(), header.Number.Uint64()), nil
+}
+func (b *testBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return b.chain.GetBlockByHash(hash), nil
+}
+func (b *testBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	if number, ok := blockNrOrHash.Number(); ok {
+		return b.BlockByNumber(ctx, number)
+	}
+	hash, _ := blockNrOrHash.Hash()
+	return b.BlockByHash(ctx, hash)
+}
+func (b *testBackend) GetBody(ctx context.Context, hash common.Hash, number rpc.BlockNumber) (*types.Body, error) {
+	if body := b.chain.GetBody(hash); body != nil {
+		return body, nil
+	}
+	return nil, errors.New("block body not found")
+}
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	return b.StateAndHeaderByNumberOrHash(ctx, rpc.BlockNumberOrHashWithNumber(number))
+}
+func (b *testBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	header, err := b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header == nil {
+		return nil, nil, errors.New("header not found")
+	}
+	statedb, err := b.chain.StateAt(header.Root)
+	return statedb, header, err
+}
+func (b *testBackend) PendingBlockAndReceipts() (*types.Block, types.Receipts) { return nil, nil }
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.chain.GetReceiptsByHash(hash), nil
+}
+func (b *testBackend) GetTd(ctx context.Context, hash common.Hash) *big.Int {
+	if header := b.chain.GetHeaderByHash(hash); header != nil {
+		return b.chain.GetTd(hash, header.Number.Uint64())
+	}
+	return nil
+}
+func (b *testBackend) GetEVM(ctx context.Context, msg *core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config, blockCtx *vm.BlockContext) (*vm.EVM, func() error, error) {
+	if vmConfig == nil {
+		vmConfig = b.chain.GetVMConfig()
+	}
+	context := core.NewEVMBlockContext(header, b.chain, nil)
+	if blockCtx != nil {
+		context = *blockCtx
+	}
+	return vm.NewEVM(context, core.NewEVMTxContext(msg), state, b.chain.Config(), *vmConfig), state.Error, nil
+}
+func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
+	return b.chain.SubscribeChainEvent(ch)
+}
+func (b *testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return b.chain.SubscribeChainHeadEvent(ch)
+}
+func (b *testBackend) SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) event.Subscription {
+	return b.chain.SubscribeChainSideEvent(ch)
+}
+func (b *testBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	b.pending[signedTx.Hash()] = signedTx
+	return nil
+}
+func (b *testBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(b.db, txHash)
+	return tx, blockHash, blockNumber, index, nil
+}
+func (b *testBackend) GetPoolTransactions() (types.Transactions, error) { return nil, nil }
+func (b *testBackend) GetPoolTransaction(txHash common.Hash) *types.Transaction {
+	return b.pending[txHash]
+}
+func (b *testBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	return 0, nil
+}
+func (b *testBackend) Stats() (pending int, queued int) { return 0, 0 }
+func (b *testBackend) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
+	return nil, nil
+}
+func (b *testBackend) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	return nil, nil
+}
+func (b *testBackend) SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription { return nil }
+func (b *testBackend) ChainConfig() *params.ChainConfig                                { return b.chain.Config() }
+func (b *testBackend) Engine() consensus.Engine                                        { return b.chain.Engine() }
+func (b *testBackend) GetLogs(ctx context.Context, blockHash common.Hash, number uint64) ([][]*types.Log, error) {
+	return rawdb.ReadLogs(b.db, blockHash, number, b.chain.Config()), nil
+}
+func (b *testBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
+	return nil
+}
+func (b *testBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription { return nil }
+func (b *testBackend) SubscribePendingLogsEvent(ch chan<- []*types.Log) event.Subscription {
+	return nil
+}
+func (b *testBackend) BloomStatus() (uint64, uint64)                                        { return 0, 0 }
+func (b *testBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {}
+
+func TestCallMany(t *testing.T) {
+	t.Parallel()
+
+	var (
+		backend = newSimulateBackend(t)
+		api     = NewBlockChainAPI(backend)
+		latest  = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		word    = common.LeftPadBytes([]byte{0x2a}, 32)
+
+		// Reverts with Error("boom"), copying the reason from the code
+		reason   = common.FromHex("08c379a0" + "0000000000000000000000000000000000000000000000000000000000000020" + "0000000000000000000000000000000000000000000000000000000000000004" + "626f6f6d00000000000000000000000000000000000000000000000000000000")
+		reverter = common.HexToAddress("0xb00b")
+		code     = append(common.FromHex("6064600c60003960646000fd"), reason...)
+	)
+	overrides := &StateOverride{reverter: OverrideAccount{Code: (*hexutil.Bytes)(&code)}}
+	results, err := api.CallMany(context.Background(), []TransactionArgs{
+		simCall(simStorer, word),
+		simCall(reverter, nil),
+		simCall(simStorer, nil),
+	}, latest, overrides, nil)
+	if err != nil {
+		t.Fatalf("failed to call: %v", err)
+	}
+	if have, want := results[1].Error, "execution reverted: boom"; have != want {
+		t.Errorf("revert error mismatch: have %q, want %q", have, want)
+	}
+	// The revert doesn't abort the sequence and the read sees the earlier store
+	if have := results[2].ReturnData; !bytes.Equal(have, word) {
+		t.Errorf("return data mismatch: have %x, want %x", have, word)
+	}
+	if results[2].Error != "" {
+		t.Errorf("unexpected error: %v", results[2].Error)
+	}
+	// The calls don't modify the chain state
+	results, err = api.CallMany(context.Background(), []TransactionArgs{simCall(simStorer, nil)}, latest, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to call: %v", err)
+	}
+	if have := results[0].ReturnData; !bytes.Equal(have, make([]byte, 32)) {
+		t.Errorf("return data mismatch: have %x, want zero word", have)
+	}
+}
+
+func TestCallManyLimits(t *testing.T) {
+	t.Parallel()
+
+	var (
+		backend = newSimulateBackend(t)
+		api     = NewBlockChainAPI(backend)
+		latest  = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	)
+	calls := make([]TransactionArgs, maxCallManyCalls+1)
+	for i := range calls {
+		calls[i] = simCall(simStorer, nil)
+	}
+	if _, err := api.CallMany(context.Background(), calls, latest, nil, nil); err == nil || !strings.HasPrefix(err.Error(), "too many calls") {
+		t.Errorf("error mismatch: have %v, want too many calls", err)
+	}
+	// The first call burns the whole gas cap, so the next one must be rejected
+	backend.gasCap = 100_000
+	_, err := api.CallMany(context.Background(), []TransactionArgs{simCall(simInvalid, nil), simCall(simStorer, nil)}, latest, nil, nil)
+	if want := "call 1: gas cap of 100000 exhausted"; err == nil || err.Error() != want {
+		t.Errorf("error mismatch: have %v, want %q", err, want)
+	}
+}
+
+func TestGetTransactionsByHashes(t *testing.T) {
+	t.Parallel()
+
+	var (
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		to      = common.HexToAddress("0xdead")
+		signer  = types.LatestSigner(params.TestChainConfig)
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+		}
+		mined []common.Hash
+	)
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {
+		for j := 0; j < 2; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), to, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+			b.AddTx(tx)
+			mined = append(mined, tx.Hash())
+		}
+	})
+	pending, _ := types.SignTx(types.NewTransaction(2, to, big.NewInt(1), params.TxGas, big.NewInt(params.InitialBaseFee), nil), signer, key)
+	backend.pending[pending.Hash()] = pending
+
+	api := NewTransactionAPI(backend, new(AddrLocker))
+
+	// The transactions are returned in the requested order, unknown ones as null
+	hashes := []common.Hash{mined[1], {0x01}, pending.Hash(), mined[0]}
+	txs, err := api.GetTransactionsByHashes(context.Background(), hashes)
+	if err != nil {
+		t.Fatalf("failed to get transactions: %v", err)
+	}
+	if len(txs) != len(hashes) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(txs), len(hashes))
+	}
+	for i, hash := range hashes {
+		if i == 1 {
+			if txs[i] != nil {
+				t.Errorf("transaction %d: unknown transaction returned: %v", i, txs[i])
+			}
+			continue
+		}
+		if txs[i] == nil || txs[i].Hash != hash {
+			t.Errorf("transaction %d: hash mismatch: have %v, want %x", i, txs[i], hash)
+		}
+	}
+	if txs[0].BlockHash == nil || txs[0].TransactionIndex == nil || uint64(*txs[0].TransactionIndex) != 1 {
+		t.Errorf("mined transaction position mismatch: have %v at %v", txs[0].BlockHash, txs[0].TransactionIndex)
+	}
+	if txs[2].BlockHash != nil {
+		t.Errorf("pending transaction has block hash %x", *txs[2].BlockHash)
+	}
+	// The number of lookups in a single request is limited
+	if _, err := api.GetTransactionsByHashes(context.Background(), make([]common.Hash, maxTransactionLookups+1)); err == nil {
+		t.Errorf("expected error looking up %d transactions", maxTransactionLookups+1)
+	}
+	if _, err := api.GetTransactionsByHashes(context.Background(), make([]common.Hash, maxTransactionLookups)); err != nil {
+		t.Errorf("failed to look up %d transactions: %v", maxTransactionLookups, err)
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+const (
+	// interfaceProbeGas is the gas allowance of a single supportsInterface
+	// query, as mandated by EIP-165.
+	interfaceProbeGas = 30000
+
+	// interfaceCacheSize is the number of distinct contract codes whose
+	// interface support is retained in memory.
+	interfaceCacheSize = 4096
+)
+
+var (
+	// supportsInterfaceSelector is the 4 byte selector of supportsInterface(bytes4).
+	supportsInterfaceSelector = []byte{0x01, 0xff, 0xc9, 0xa7}
+
+	// invalidInterfaceID is the interface identifier EIP-165 compliant
+	// contracts must report as unsupported.
+	invalidInterfaceID = [4]byte{0xff, 0xff, 0xff, 0xff}
+
+	// eip1967ImplementationSlot is the storage slot EIP-1967 proxies keep the
+	// address of their implementation contract in.
+	eip1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+
+	// eip1967BeaconSlot is the storage slot EIP-1967 beacon proxies keep the
+	// address of their beacon contract in.
+	eip1967BeaconSlot = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+
+	// knownInterfaces are the interfaces probed on contracts that implement
+	// EIP-165, along with the result field each one is reported in.
+	knownInterfaces = []struct {
+		id    [4]byte
+		field func(*InterfaceSupport) *bool
+	}{
+		{[4]byte{0x80, 0xac, 0x58, 0xcd}, func(s *InterfaceSupport) *bool { return &s.ERC721 }},
+		{[4]byte{0xd9, 0xb6, 0x7a, 0x26}, func(s *InterfaceSupport) *bool { return &s.ERC1155 }},
+		{[4]byte{0x2a, 0x55, 0x20, 0x5a}, func(s *InterfaceSupport) *bool { return &s.ERC2981 }},
+		{[4]byte{0x87, 0xdf, 0xe5, 0xa0}, func(s *InterfaceSupport) *bool { return &s.ERC4626 }},
+		{[4]byte{0x16, 0x26, 0xba, 0x7e}, func(s *InterfaceSupport) *bool { return &s.ERC1271 }},
+	}
+)
+
+// InterfaceSupport reports which well known interfaces a contract advertises
+// through EIP-165.
+type InterfaceSupport struct {
+	ERC165  bool `json:"erc165"`
+	ERC721  bool `json:"erc721"`
+	ERC1155 bool `json:"erc1155"`
+	ERC2981 bool `json:"erc2981"`
+	ERC4626 bool `json:"erc4626"`
+	ERC1271 bool `json:"erc1271"`
+}
+
+// InterfaceProber detects the interfaces supported by contracts, caching the
+// results by code hash so that contracts sharing the same code are probed
+// only once.
+//
+// The results of EIP-1967 proxies are cached by the code hash of both the proxy
+// and its implementation, as proxies sharing the same code may delegate to
+// different implementations. Other contracts delegating their execution (e.g.
+// EIP-1167 clones or proxies keeping their implementation elsewhere in storage)
+// and beacon proxies are not cached at all, since their answer doesn't follow
+// from their code.
+type InterfaceProber struct {
+	cache *lru.Cache[interfaceCacheKey, InterfaceSupport]
+}
+
+// interfaceCacheKey identifies the code determining the interfaces supported
+// by a contract. The implementation is only set for EIP-1967 proxies.
+type interfaceCacheKey struct {
+	code           common.Hash
+	implementation common.Hash
+}
+
+// NewInterfaceProber creates an interface prober with an empty cache.
+func NewInterfaceProber() *InterfaceProber {
+	return &InterfaceProber{
+		cache: lru.NewCache[interfaceCacheKey, InterfaceSupport](interfaceCacheSize),
+	}
+}
+
+// Probe detects the interfaces supported by the contract at the given address
+// in the given state. The queries run as static calls, so they can't modify
+// the state.
+func (p *InterfaceProber) Probe(ctx context.Context, b Backend, statedb *state.StateDB, header *types.Header, address common.Address) (*InterfaceSupport, error) {
+	codeHash := statedb.GetCodeHash(address)
+	if codeHash == (common.Hash{}) || codeHash == types.EmptyCodeHash {
+		return new(InterfaceSupport), nil
+	}
+	var (
+		key       = interfaceCacheKey{code: codeHash}
+		cacheable = statedb.GetState(address, eip1967BeaconSlot) == (common.Hash{})
+	)
+	if implementation := statedb.GetState(address, eip1967ImplementationSlot); implementation != (common.Hash{}) {
+		key.implementation = statedb.GetCodeHash(common.BytesToAddress(implementation.Bytes()))
+	} else if delegates(statedb.GetCode(address)) {
+		cacheable = false
+	}
+	if cacheable {
+		if support, ok := p.cache.Get(key); ok {
+			return &support, nil
+		}
+	}
+	call := func(input []byte) (*core.ExecutionResult, error) {
+		return probeCall(ctx, b, statedb, header, address, input)
+	}
+	support, err := probeInterfaces(call)
+	if err != nil {
+		return nil, err
+	}
+	if cacheable {
+		p.cache.Add(key, *support)
+	}
+	return support, nil
+}
+
+// delegates reports whether the given code may execute other code in its own
+// context, through DELEGATECALL or CALLCODE.
+func delegates(code []byte) bool {
+	for i := 0; i < len(code); i++ {
+		op := vm.OpCode(code[i])
+		switch {
+		case op == vm.DELEGATECALL || op == vm.CALLCODE:
+			return true
+		case op.IsPush():
+			i += int(op - vm.PUSH1 + 1)
+		}
+	}
+	return false
+}
+
+// probeCall executes a single supportsInterface query against a contract as a
+// static call, so the contract can't spend its allowance on state changes.
+func probeCall(ctx context.Context, b Backend, statedb *state.StateDB, header *types.Header, address common.Address, input []byte) (*core.ExecutionResult, error) {
+	msg := &core.Message{
+		To:                &address,
+		Value:             new(big.Int),
+		GasLimit:          interfaceProbeGas,
+		GasPrice:          new(big.Int),
+		GasFeeCap:         new(big.Int),
+		GasTipCap:         new(big.Int),
+		Data:              input,
+		SkipAccountChecks: true,
+	}
+	evm, vmError, err := b.GetEVM(ctx, msg, statedb, header, &vm.Config{NoBaseFee: true}, nil)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+	rules := evm.ChainConfig().Rules(evm.Context.BlockNumber, evm.Context.Random != nil, evm.Context.Time)
+	statedb.Prepare(rules, msg.From, evm.Context.Coinbase, msg.To, vm.ActivePrecompiles(rules), nil)
+
+	ret, gas, err := evm.StaticCall(vm.AccountRef(msg.From), address, input, msg.GasLimit)
+	if err := vmError(); err != nil {
+		return nil, err
+	}
+	if evm.Cancelled() {
+		return nil, fmt.Errorf("execution aborted probing %v", address)
+	}
+	// Execution errors are the outcome of the query, not a failure to probe
+	return &core.ExecutionResult{UsedGas: msg.GasLimit - gas, Err: err, ReturnData: ret}, nil
+}
+
+// probeInterfaces runs the EIP-165 detection procedure, using call to execute
+// the individual supportsInterface queries. The remaining interfaces are only
+// probed if the contract correctly implements EIP-165 itself.
+func probeInterfaces(call func(input []byte) (*core.ExecutionResult, error)) (*InterfaceSupport, error) {
+	supports := func(id [4]byte) (bool, error) {
+		input := make([]byte, 4+32)
+		copy(input, supportsInterfaceSelector)
+		copy(input[4:], id[:])
+
+		result, err := call(input)
+		if err != nil {
+			return false, err
+		}
+		if result.Failed() || len(result.ReturnData) < 32 {
+			return false, nil
+		}
+		return new(big.Int).SetBytes(result.ReturnData[:32]).Cmp(common.Big1) == 0, nil
+	}
+	support := new(InterfaceSupport)
+
+	var selectorID [4]byte
+	copy(selectorID[:], supportsInterfaceSelector)
+	if ok, err := supports(selectorID); err != nil || !ok {
+		return support, err
+	}
+	if ok, err := supports(invalidInterfaceID); err != nil || ok {
+		return support, err
+	}
+	support.ERC165 = true
+
+	for _, iface := range knownInterfaces {
+		ok, err := supports(iface.id)
+		if err != nil {
+			return nil, err
+		}
+		*iface.field(support) = ok
+	}
+	return support, nil
+}
@@ -0,0 +1,216 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakeInterfaceContract returns a supportsInterface implementation answering
+// true for the given interface identifiers.
+func fakeInterfaceContract(ids ...[4]byte) func(input []byte) (*core.ExecutionResult, error) {
+	return func(input []byte) (*core.ExecutionResult, error) {
+		if !bytes.Equal(input[:4], supportsInterfaceSelector) {
+			return &core.ExecutionResult{Err: vm.ErrExecutionReverted}, nil
+		}
+		for _, id := range ids {
+			if bytes.Equal(input[4:8], id[:]) {
+				return &core.ExecutionResult{ReturnData: common.LeftPadBytes([]byte{1}, 32)}, nil
+			}
+		}
+		return &core.ExecutionResult{ReturnData: make([]byte, 32)}, nil
+	}
+}
+
+func TestProbeInterfaces(t *testing.T) {
+	var erc165 [4]byte
+	copy(erc165[:], supportsInterfaceSelector)
+
+	tests := []struct {
+		call func(input []byte) (*core.ExecutionResult, error)
+		want InterfaceSupport
+		err  bool
+	}{
+		// Contract without supportsInterface
+		{
+			call: func(input []byte) (*core.ExecutionResult, error) {
+				return &core.ExecutionResult{Err: vm.ErrExecutionReverted}, nil
+			},
+		},
+		// Contract answering true for everything, including the invalid id
+		{
+			call: func(input []byte) (*core.ExecutionResult, error) {
+				return &core.ExecutionResult{ReturnData: common.LeftPadBytes([]byte{1}, 32)}, nil
+			},
+		},
+		// Contract returning short data
+		{
+			call: func(input []byte) (*core.ExecutionResult, error) {
+				return &core.ExecutionResult{ReturnData: []byte{1}}, nil
+			},
+		},
+		// Plain EIP-165 contract
+		{
+			call: fakeInterfaceContract(erc165),
+			want: InterfaceSupport{ERC165: true},
+		},
+		// NFT with royalties
+		{
+			call: fakeInterfaceContract(erc165, knownInterfaces[0].id, knownInterfaces[2].id),
+			want: InterfaceSupport{ERC165: true, ERC721: true, ERC2981: true},
+		},
+		// Multi token and smart account
+		{
+			call: fakeInterfaceContract(erc165, knownInterfaces[1].id, knownInterfaces[4].id),
+			want: InterfaceSupport{ERC165: true, ERC1155: true, ERC1271: true},
+		},
+		// Execution failures are propagated
+		{
+			call: func(input []byte) (*core.ExecutionResult, error) {
+				return nil, errors.New("aborted")
+			},
+			err: true,
+		},
+	}
+	for i, tt := range tests {
+		have, err := probeInterfaces(tt.call)
+		if tt.err {
+			if err == nil {
+				t.Errorf("test %d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: failed to probe interfaces: %v", i, err)
+		}
+		if *have != tt.want {
+			t.Errorf("test %d: support mismatch: have %+v, want %+v", i, *have, tt.want)
+		}
+	}
+}
+
+// countingBackend counts the EVM executions of a backend.
+type countingBackend struct {
+	*testBackend
+	calls int
+}
+
+func (b *countingBackend) GetEVM(ctx context.Context, msg *core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config, blockCtx *vm.BlockContext) (*vm.EVM, func() error, error) {
+	b.calls++
+	return b.testBackend.GetEVM(ctx, msg, state, header, vmConfig, blockCtx)
+}
+
+func TestInterfaceProberCache(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Supports ERC-165 and ERC-721
+		nft = common.FromHex("60043560e01c806301ffc9a714602457806380ac58cd14602457600060005260206000f35b600160005260206000f3")
+		// Supports ERC-165 only
+		plain = common.FromHex("60043560e01c806301ffc9a714601a57600060005260206000f35b600160005260206000f3")
+		// Delegates to the implementation in the EIP-1967 slot
+		proxy = common.FromHex("366000600037602060003660007f" + eip1967ImplementationSlot.Hex()[2:] + "545af45060206000f3")
+		// Delegates to the implementation in slot 0, like a Gnosis Safe proxy
+		safe = common.FromHex("366000600037602060003660006000545af45060206000f3")
+		// Supports ERC-165, but writes to storage before answering
+		writer = common.FromHex("60043560e01c806301ffc9a714601a57600060005260206000f35b6001600055600160005260206000f3")
+
+		nftImpl     = common.HexToAddress("0x1001")
+		plainImpl   = common.HexToAddress("0x1002")
+		nftCopy     = common.HexToAddress("0x1003")
+		nftProxy    = common.HexToAddress("0x2001")
+		plainProxy  = common.HexToAddress("0x2002")
+		beaconProxy = common.HexToAddress("0x2003")
+		nftSafe     = common.HexToAddress("0x2004")
+		plainSafe   = common.HexToAddress("0x2005")
+		writing     = common.HexToAddress("0x3001")
+	)
+	slots := func(implementation common.Address, beacon bool) map[common.Hash]common.Hash {
+		storage := map[common.Hash]common.Hash{eip1967ImplementationSlot: common.BytesToHash(implementation.Bytes())}
+		if beacon {
+			storage[eip1967BeaconSlot] = common.BytesToHash(common.HexToAddress("0xbeac0").Bytes())
+		}
+		return storage
+	}
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			nftImpl:     {Balance: common.Big0, Code: nft},
+			plainImpl:   {Balance: common.Big0, Code: plain},
+			nftCopy:     {Balance: common.Big0, Code: nft},
+			nftProxy:    {Balance: common.Big0, Code: proxy, Storage: slots(nftImpl, false)},
+			plainProxy:  {Balance: common.Big0, Code: proxy, Storage: slots(plainImpl, false)},
+			beaconProxy: {Balance: common.Big0, Code: proxy, Storage: slots(nftImpl, true)},
+			nftSafe:     {Balance: common.Big0, Code: safe, Storage: map[common.Hash]common.Hash{{}: common.BytesToHash(nftImpl.Bytes())}},
+			plainSafe:   {Balance: common.Big0, Code: safe, Storage: map[common.Hash]common.Hash{{}: common.BytesToHash(plainImpl.Bytes())}},
+			writing:     {Balance: common.Big0, Code: writer},
+		},
+	}
+	backend := &countingBackend{testBackend: newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {})}
+	statedb, header, err := backend.StateAndHeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to retrieve state: %v", err)
+	}
+	prober := NewInterfaceProber()
+
+	tests := []struct {
+		address common.Address
+		want    InterfaceSupport
+		cached  bool // Whether the result is expected to come from the cache
+		entries int  // Number of cache entries after probing
+	}{
+		{nftImpl, InterfaceSupport{ERC165: true, ERC721: true}, false, 1},
+		{plainImpl, InterfaceSupport{ERC165: true}, false, 2},
+		{nftCopy, InterfaceSupport{ERC165: true, ERC721: true}, true, 2},
+		{nftProxy, InterfaceSupport{ERC165: true, ERC721: true}, false, 3},
+		{plainProxy, InterfaceSupport{ERC165: true}, false, 4},
+		{plainProxy, InterfaceSupport{ERC165: true}, true, 4},
+		{beaconProxy, InterfaceSupport{ERC165: true, ERC721: true}, false, 4},
+		{beaconProxy, InterfaceSupport{ERC165: true, ERC721: true}, false, 4},
+		{nftSafe, InterfaceSupport{ERC165: true, ERC721: true}, false, 4},
+		{plainSafe, InterfaceSupport{ERC165: true}, false, 4},
+		{plainSafe, InterfaceSupport{ERC165: true}, false, 4},
+		{writing, InterfaceSupport{}, false, 5},
+	}
+	for i, tt := range tests {
+		calls := backend.calls
+		have, err := prober.Probe(context.Background(), backend, statedb, header, tt.address)
+		if err != nil {
+			t.Fatalf("test %d: failed to probe %x: %v", i, tt.address, err)
+		}
+		if *have != tt.want {
+			t.Errorf("test %d: support mismatch: have %+v, want %+v", i, *have, tt.want)
+		}
+		if cached := backend.calls == calls; cached != tt.cached {
+			t.Errorf("test %d: cache hit mismatch: have %v, want %v", i, cached, tt.cached)
+		}
+		if have := prober.cache.Len(); have != tt.entries {
+			t.Errorf("test %d: cache size mismatch: have %d, want %d", i, have, tt.entries)
+		}
+	}
+}
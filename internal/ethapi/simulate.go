@@ -0,0 +1,568 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// maxSimulateBlocks is the maximum number of blocks that can be simulated
+	// in a single request, including the empty blocks filling number gaps.
+	maxSimulateBlocks = 256
+
+	// simulateBlockTime is the timestamp increment applied to simulated blocks
+	// which don't override their time.
+	simulateBlockTime = 12
+)
+
+// Error codes of eth_simulateV1 as defined by the execution API specification.
+const (
+	errCodeReverted              = 3
+	errCodeInvalidParams         = -32602
+	errCodeVMError               = -32015
+	errCodeBlockNumberInvalid    = -38020
+	errCodeBlockTimestampInvalid = -38021
+	errCodeClientLimitExceeded   = -38026
+)
+
+// simError is an eth_simulateV1 error carrying a standardised error code.
+type simError struct {
+	code    int
+	message string
+}
+
+func (e *simError) Error() string  { return e.message }
+func (e *simError) ErrorCode() int { return e.code }
+
+// simBlockOverrides is the set of header fields a simulated block can
+// override, named as in the eth_simulateV1 specification.
+type simBlockOverrides struct {
+	Number        *hexutil.Big      `json:"number"`
+	Difficulty    *hexutil.Big      `json:"difficulty"`
+	Time          *hexutil.Uint64   `json:"time"`
+	GasLimit      *hexutil.Uint64   `json:"gasLimit"`
+	FeeRecipient  *common.Address   `json:"feeRecipient"`
+	PrevRandao    *common.Hash      `json:"prevRandao"`
+	BaseFeePerGas *hexutil.Big      `json:"baseFeePerGas"`
+	Withdrawals   types.Withdrawals `json:"withdrawals"`
+}
+
+// UnmarshalJSON decodes the block overrides, rejecting unknown fields so that
+// unsupported overrides are not silently ignored.
+func (o *simBlockOverrides) UnmarshalJSON(input []byte) error {
+	type overrides simBlockOverrides
+	var dec overrides
+
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&dec); err != nil {
+		return fmt.Errorf("invalid block overrides: %w", err)
+	}
+	*o = simBlockOverrides(dec)
+	return nil
+}
+
+// simBlock is a batch of calls to be simulated in a single block, on top of
+// the optionally overridden state and block fields.
+type simBlock struct {
+	BlockOverrides *simBlockOverrides `json:"blockOverrides"`
+	StateOverrides *StateOverride     `json:"stateOverrides"`
+	Calls          []TransactionArgs  `json:"calls"`
+}
+
+// simOpts are the inputs of eth_simulateV1.
+type simOpts struct {
+	BlockStateCalls        []simBlock `json:"blockStateCalls"`
+	TraceTransfers         bool       `json:"traceTransfers"`
+	Validation             bool       `json:"validation"`
+	ReturnFullTransactions bool       `json:"returnFullTransactions"`
+}
+
+// UnmarshalJSON decodes the simulation options, rejecting unknown options so
+// that unsupported ones are not silently ignored. The calls themselves are
+// decoded as leniently as eth_call does.
+func (o *simOpts) UnmarshalJSON(input []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(input, &fields); err != nil {
+		return err
+	}
+	for name := range fields {
+		switch name {
+		case "blockStateCalls", "traceTransfers", "validation", "returnFullTransactions":
+		default:
+			return fmt.Errorf("unknown simulation option %q", name)
+		}
+	}
+	type opts simOpts
+	var dec opts
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	*o = simOpts(dec)
+	return nil
+}
+
+// simCallError is the error of a single failed call.
+type simCallError struct {
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+	Data    string `json:"data,omitempty"`
+}
+
+// simCallResult is the outcome of a single simulated call.
+type simCallResult struct {
+	ReturnValue hexutil.Bytes  `json:"returnData"`
+	Logs        []*types.Log   `json:"logs"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	Status      hexutil.Uint64 `json:"status"`
+	Error       *simCallError  `json:"error,omitempty"`
+}
+
+// simChainContext resolves the headers of both the canonical chain and the
+// blocks simulated so far, so BLOCKHASH works across simulated blocks.
+type simChainContext struct {
+	core.ChainContext
+	headers map[common.Hash]*types.Header
+}
+
+// GetHeader returns the simulated header of the given hash, or falls back to
+// the canonical chain.
+func (c *simChainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header, ok := c.headers[hash]; ok {
+		return header
+	}
+	return c.ChainContext.GetHeader(hash, number)
+}
+
+// simulator runs eth_simulateV1 requests on top of a base block.
+type simulator struct {
+	b        Backend
+	state    *state.StateDB
+	base     *types.Header
+	chain    *simChainContext
+	validate bool
+	fullTx   bool
+
+	capped    bool   // Whether the request is limited by the RPC gas cap
+	remaining uint64 // Gas remaining of the request, if capped
+}
+
+// SimulateV1 executes series of transactions on top of a base state, grouped
+// into a sequence of simulated blocks. The transactions don't need to be
+// signed, state changes carry over from one call and block to the next, and
+// both state and block fields can be overridden before each block.
+//
+// The resulting blocks are returned in the eth_getBlockByNumber format, each
+// extended with the outcome and logs of its calls.
+func (s *BlockChainAPI) SimulateV1(ctx context.Context, opts simOpts, blockNrOrHash *rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	if len(opts.BlockStateCalls) == 0 {
+		return nil, &simError{code: errCodeInvalidParams, message: "empty input"}
+	}
+	if len(opts.BlockStateCalls) > maxSimulateBlocks {
+		return nil, &simError{code: errCodeClientLimitExceeded, message: "too many blocks"}
+	}
+	if opts.TraceTransfers {
+		return nil, &simError{code: errCodeInvalidParams, message: "traceTransfers is not supported"}
+	}
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	statedb, base, err := s.b.StateAndHeaderByNumberOrHash(ctx, bNrOrHash)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	// The whole request shares the global call timeout and gas cap
+	if timeout := s.b.RPCEVMTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	sim := &simulator{
+		b:     s.b,
+		state: statedb,
+		base:  base,
+		chain: &simChainContext{
+			ChainContext: NewChainContext(ctx, s.b),
+			headers:      make(map[common.Hash]*types.Header),
+		},
+		validate:  opts.Validation,
+		fullTx:    opts.ReturnFullTransactions,
+		capped:    s.b.RPCGasCap() != 0,
+		remaining: s.b.RPCGasCap(),
+	}
+	return sim.execute(ctx, opts.BlockStateCalls)
+}
+
+// execute runs the given blocks in order on top of the base block.
+func (sim *simulator) execute(ctx context.Context, blocks []simBlock) ([]map[string]interface{}, error) {
+	defer func(start time.Time) { log.Debug("Simulating blocks finished", "runtime", time.Since(start)) }(time.Now())
+
+	blocks, err := sanitizeChain(sim.base, blocks)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		results = make([]map[string]interface{}, 0, len(blocks))
+		parent  = sim.base
+	)
+	for _, block := range blocks {
+		result, header, err := sim.processBlock(ctx, &block, parent)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+		parent = header
+	}
+	return results, nil
+}
+
+// sanitizeChain checks that the block numbers and timestamps of the requested
+// blocks are strictly increasing, assigning defaults to the ones not set and
+// filling any gaps in the numbering with empty blocks.
+func sanitizeChain(base *types.Header, blocks []simBlock) ([]simBlock, error) {
+	var (
+		res        = make([]simBlock, 0, len(blocks))
+		prevNumber = new(big.Int).Set(base.Number)
+		prevTime   = base.Time
+	)
+	for _, block := range blocks {
+		if block.BlockOverrides == nil {
+			block.BlockOverrides = new(simBlockOverrides)
+		}
+		if block.BlockOverrides.Number == nil {
+			n := new(big.Int).Add(prevNumber, common.Big1)
+			block.BlockOverrides.Number = (*hexutil.Big)(n)
+		}
+		number := block.BlockOverrides.Number.ToInt()
+		diff := new(big.Int).Sub(number, prevNumber)
+		if diff.Sign() <= 0 {
+			return nil, &simError{code: errCodeBlockNumberInvalid, message: fmt.Sprintf("block numbers must be in order: %d <= %d", number, prevNumber)}
+		}
+		if total := new(big.Int).Sub(number, base.Number); total.Cmp(big.NewInt(maxSimulateBlocks)) > 0 {
+			return nil, &simError{code: errCodeClientLimitExceeded, message: "too many blocks"}
+		}
+		// Fill the gap between the previous block and this one with empty blocks
+		for gap := diff.Uint64(); gap > 1; gap-- {
+			prevNumber = new(big.Int).Add(prevNumber, common.Big1)
+			prevTime += simulateBlockTime
+			t := hexutil.Uint64(prevTime)
+			res = append(res, simBlock{BlockOverrides: &simBlockOverrides{Number: (*hexutil.Big)(prevNumber), Time: &t}})
+		}
+		if block.BlockOverrides.Time == nil {
+			t := hexutil.Uint64(prevTime + simulateBlockTime)
+			block.BlockOverrides.Time = &t
+		} else if uint64(*block.BlockOverrides.Time) <= prevTime {
+			return nil, &simError{code: errCodeBlockTimestampInvalid, message: fmt.Sprintf("block timestamps must be in order: %d <= %d", *block.BlockOverrides.Time, prevTime)}
+		}
+		prevNumber = number
+		prevTime = uint64(*block.BlockOverrides.Time)
+		res = append(res, block)
+	}
+	return res, nil
+}
+
+// makeHeader assembles the header of a simulated block on top of its parent,
+// applying the block overrides. Number and Time are always set by
+// sanitizeChain.
+func (sim *simulator) makeHeader(block *simBlock, parent *types.Header) *types.Header {
+	var (
+		config    = sim.b.ChainConfig()
+		overrides = block.BlockOverrides
+	)
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		UncleHash:  types.EmptyUncleHash,
+		Coinbase:   parent.Coinbase,
+		Difficulty: new(big.Int).Set(parent.Difficulty),
+		Number:     overrides.Number.ToInt(),
+		GasLimit:   parent.GasLimit,
+		Time:       uint64(*overrides.Time),
+		MixDigest:  parent.MixDigest,
+	}
+	if config.IsLondon(header.Number) {
+		// The base block's parent may be pre-London, start from the initial fee
+		if config.IsLondon(parent.Number) {
+			header.BaseFee = misc.CalcBaseFee(config, parent)
+		} else {
+			header.BaseFee = new(big.Int).SetUint64(params.InitialBaseFee)
+		}
+	}
+	if config.IsShanghai(header.Time) {
+		header.WithdrawalsHash = &types.EmptyWithdrawalsHash
+	}
+	if overrides.Difficulty != nil {
+		header.Difficulty = overrides.Difficulty.ToInt()
+	}
+	if overrides.GasLimit != nil {
+		header.GasLimit = uint64(*overrides.GasLimit)
+	}
+	if overrides.FeeRecipient != nil {
+		header.Coinbase = *overrides.FeeRecipient
+	}
+	if overrides.PrevRandao != nil {
+		header.MixDigest = *overrides.PrevRandao
+	}
+	if overrides.BaseFeePerGas != nil {
+		header.BaseFee = overrides.BaseFeePerGas.ToInt()
+	} else if header.BaseFee != nil && !sim.validate {
+		// Without validation calls are free, keep the block consistent with that
+		header.BaseFee = new(big.Int)
+	}
+	return header
+}
+
+// processBlock simulates the calls of a single block on top of its parent,
+// returning the marshalled block and its header.
+func (sim *simulator) processBlock(ctx context.Context, block *simBlock, parent *types.Header) (map[string]interface{}, *types.Header, error) {
+	if err := block.StateOverrides.Apply(sim.state); err != nil {
+		return nil, nil, err
+	}
+	var (
+		config   = sim.b.ChainConfig()
+		header   = sim.makeHeader(block, parent)
+		blockCtx = core.NewEVMBlockContext(header, sim.chain, &header.Coinbase)
+		vmConfig = vm.Config{NoBaseFee: !sim.validate}
+		gp       = new(core.GasPool).AddGas(header.GasLimit)
+
+		logIndex uint
+
+		txs      = make([]*types.Transaction, 0, len(block.Calls))
+		senders  = make(map[common.Hash]common.Address)
+		receipts = make([]*types.Receipt, 0, len(block.Calls))
+		calls    = make([]simCallResult, 0, len(block.Calls))
+
+		withdrawals = block.BlockOverrides.Withdrawals
+	)
+	if withdrawals != nil && header.WithdrawalsHash == nil {
+		return nil, nil, &simError{code: errCodeInvalidParams, message: fmt.Sprintf("block %d: withdrawals before shanghai", header.Number)}
+	}
+	for i, call := range block.Calls {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		tx, msg, err := sim.prepareCall(&call, header, gp.Gas())
+		if err != nil {
+			return nil, nil, err
+		}
+		// Identical calls have the same hash, collect the logs by call instead
+		logsKey := simLogsKey(header.Number, i)
+		sim.state.SetTxContext(logsKey, i)
+
+		result, err := sim.applyMessage(ctx, blockCtx, vmConfig, msg, gp)
+		if err != nil {
+			return nil, nil, fmt.Errorf("block %d, call %d: %w", header.Number, i, err)
+		}
+		if sim.capped {
+			sim.remaining -= result.UsedGas
+		}
+		header.GasUsed += result.UsedGas
+
+		var root []byte
+		if config.IsByzantium(header.Number) {
+			sim.state.Finalise(true)
+		} else {
+			root = sim.state.IntermediateRoot(config.IsEIP158(header.Number)).Bytes()
+		}
+		// Log indices are tracked by the state across blocks, renumber them
+		logs := sim.state.GetLogs(logsKey, header.Number.Uint64(), common.Hash{})
+		if logs == nil {
+			logs = []*types.Log{}
+		}
+		for _, l := range logs {
+			l.TxHash = tx.Hash()
+			l.Index = logIndex
+			logIndex++
+		}
+
+		receipt := &types.Receipt{
+			Type:              tx.Type(),
+			PostState:         root,
+			CumulativeGasUsed: header.GasUsed,
+			TxHash:            tx.Hash(),
+			GasUsed:           result.UsedGas,
+			Logs:              logs,
+			BlockNumber:       header.Number,
+			TransactionIndex:  uint(i),
+		}
+		callRes := simCallResult{
+			ReturnValue: result.Return(),
+			Logs:        logs,
+			GasUsed:     hexutil.Uint64(result.UsedGas),
+			Status:      hexutil.Uint64(types.ReceiptStatusSuccessful),
+		}
+		if result.Failed() {
+			receipt.Status = types.ReceiptStatusFailed
+			callRes.Status = hexutil.Uint64(types.ReceiptStatusFailed)
+			if errors.Is(result.Err, vm.ErrExecutionReverted) {
+				revertErr := newRevertError(result)
+				callRes.Error = &simCallError{Message: revertErr.Error(), Code: errCodeReverted, Data: revertErr.reason}
+			} else {
+				callRes.Error = &simCallError{Message: result.Err.Error(), Code: errCodeVMError}
+			}
+		} else {
+			receipt.Status = types.ReceiptStatusSuccessful
+		}
+		if msg.To == nil {
+			receipt.ContractAddress = crypto.CreateAddress(msg.From, tx.Nonce())
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+		txs = append(txs, tx)
+		senders[tx.Hash()] = msg.From
+		receipts = append(receipts, receipt)
+		calls = append(calls, callRes)
+	}
+	// The withdrawals are credited once all the calls are done, amounts are in gwei
+	for _, w := range withdrawals {
+		amount := new(big.Int).SetUint64(w.Amount)
+		sim.state.AddBalance(w.Address, amount.Mul(amount, big.NewInt(params.GWei)))
+	}
+	header.Root = sim.state.IntermediateRoot(config.IsEIP158(header.Number))
+
+	var b *types.Block
+	if header.WithdrawalsHash != nil {
+		if withdrawals == nil {
+			withdrawals = types.Withdrawals{}
+		}
+		b = types.NewBlockWithWithdrawals(header, txs, nil, receipts, withdrawals, trie.NewStackTrie(nil))
+	} else {
+		b = types.NewBlock(header, txs, nil, receipts, trie.NewStackTrie(nil))
+	}
+	// The block hash is only known once assembled, patch it into the logs
+	hash := b.Hash()
+	for _, receipt := range receipts {
+		receipt.BlockHash = hash
+		for _, l := range receipt.Logs {
+			l.BlockHash = hash
+		}
+	}
+	sim.chain.headers[hash] = b.Header()
+
+	fields, err := RPCMarshalBlock(b, true, sim.fullTx, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The transactions are unsigned, fill in the senders that can't be recovered
+	// and drop the chain id derived from the empty signature of legacy ones
+	if sim.fullTx {
+		for _, tx := range fields["transactions"].([]interface{}) {
+			if rpcTx, ok := tx.(*RPCTransaction); ok {
+				rpcTx.From = senders[rpcTx.Hash]
+				if uint64(rpcTx.Type) == types.LegacyTxType {
+					rpcTx.ChainID = nil
+				}
+			}
+		}
+	}
+	fields["calls"] = calls
+	return fields, b.Header(), nil
+}
+
+// simLogsKey returns the key the logs of a simulated call are collected under
+// in the state, unique across the blocks of a simulation.
+func simLogsKey(number *big.Int, index int) common.Hash {
+	var key [40]byte
+	number.FillBytes(key[:32])
+	binary.BigEndian.PutUint64(key[32:], uint64(index))
+	return crypto.Keccak256Hash(key[:])
+}
+
+// prepareCall fills in the defaults of a call, limiting its gas to the gas
+// remaining in the block and in the request budget, and converts it both into
+// a transaction for the simulated block and a message for execution.
+func (sim *simulator) prepareCall(args *TransactionArgs, header *types.Header, blockGas uint64) (*types.Transaction, *core.Message, error) {
+	if args.Data != nil && args.Input != nil && !bytes.Equal(*args.Data, *args.Input) {
+		return nil, nil, &simError{code: errCodeInvalidParams, message: `both "data" and "input" are set and not equal. Please use "input" to pass transaction call data`}
+	}
+	gasCap := blockGas
+	if sim.capped {
+		if sim.remaining == 0 {
+			return nil, nil, &simError{code: errCodeClientLimitExceeded, message: fmt.Sprintf("gas cap of %d exhausted", sim.b.RPCGasCap())}
+		}
+		if sim.remaining < gasCap {
+			gasCap = sim.remaining
+		}
+	}
+	if args.Gas == nil {
+		gas := hexutil.Uint64(gasCap)
+		args.Gas = &gas
+	}
+	if args.Nonce == nil {
+		nonce := hexutil.Uint64(sim.state.GetNonce(args.from()))
+		args.Nonce = &nonce
+	}
+	if args.ChainID == nil {
+		args.ChainID = (*hexutil.Big)(sim.b.ChainConfig().ChainID)
+	}
+	// Cap the gas by the remaining budget, checked above to be non-zero since
+	// a zero cap would lift the limit altogether
+	var budget uint64
+	if sim.capped {
+		budget = sim.remaining
+	}
+	msg, err := args.ToMessage(budget, header.BaseFee)
+	if err != nil {
+		return nil, nil, err
+	}
+	msg.Nonce = uint64(*args.Nonce)
+	msg.SkipAccountChecks = !sim.validate
+
+	// ToMessage may have capped the gas, keep the transaction consistent
+	gas := hexutil.Uint64(msg.GasLimit)
+	args.Gas = &gas
+	return args.ToTransaction(), msg, nil
+}
+
+// applyMessage executes a message on the simulation state, aborting it if the
+// request context is cancelled meanwhile.
+func (sim *simulator) applyMessage(ctx context.Context, blockCtx vm.BlockContext, vmConfig vm.Config, msg *core.Message, gp *core.GasPool) (*core.ExecutionResult, error) {
+	evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), sim.state, sim.b.ChainConfig(), vmConfig)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+	result, err := core.ApplyMessage(evm, msg, gp)
+	if evm.Cancelled() {
+		return nil, errors.New("execution aborted (timeout)")
+	}
+	return result, err
+}
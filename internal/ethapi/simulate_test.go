@@ -0,0 +1,477 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestSimulateSanitizeChain(t *testing.T) {
+	type block struct {
+		number uint64
+		time   uint64
+	}
+	var (
+		base = &types.Header{Number: big.NewInt(10), Time: 100}
+		num  = func(n uint64) *hexutil.Big { return (*hexutil.Big)(new(big.Int).SetUint64(n)) }
+		ts   = func(t uint64) *hexutil.Uint64 { return (*hexutil.Uint64)(&t) }
+	)
+	tests := []struct {
+		blocks []simBlock
+		want   []block
+		code   int
+	}{
+		// Defaults follow the base block
+		{
+			blocks: []simBlock{{}, {}},
+			want:   []block{{11, 112}, {12, 124}},
+		},
+		// Number gaps are filled with empty blocks
+		{
+			blocks: []simBlock{{BlockOverrides: &simBlockOverrides{Number: num(13)}}, {}},
+			want:   []block{{11, 112}, {12, 124}, {13, 136}, {14, 148}},
+		},
+		// Explicit timestamps are honoured and carried forward
+		{
+			blocks: []simBlock{{BlockOverrides: &simBlockOverrides{Time: ts(1000)}}, {BlockOverrides: &simBlockOverrides{Number: num(13)}}},
+			want:   []block{{11, 1000}, {12, 1012}, {13, 1024}},
+		},
+		// Block numbers must increase
+		{
+			blocks: []simBlock{{BlockOverrides: &simBlockOverrides{Number: num(10)}}},
+			code:   errCodeBlockNumberInvalid,
+		},
+		// Timestamps must increase
+		{
+			blocks: []simBlock{{}, {BlockOverrides: &simBlockOverrides{Time: ts(112)}}},
+			code:   errCodeBlockTimestampInvalid,
+		},
+		// Gaps count towards the block limit
+		{
+			blocks: []simBlock{{BlockOverrides: &simBlockOverrides{Number: num(10 + maxSimulateBlocks + 1)}}},
+			code:   errCodeClientLimitExceeded,
+		},
+	}
+	for i, tt := range tests {
+		have, err := sanitizeChain(base, tt.blocks)
+		if tt.code != 0 {
+			var simErr *simError
+			if !errors.As(err, &simErr) || simErr.ErrorCode() != tt.code {
+				t.Errorf("test %d: error mismatch: have %v, want code %d", i, err, tt.code)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: failed to sanitize chain: %v", i, err)
+		}
+		if len(have) != len(tt.want) {
+			t.Fatalf("test %d: block count mismatch: have %d, want %d", i, len(have), len(tt.want))
+		}
+		for j, want := range tt.want {
+			overrides := have[j].BlockOverrides
+			if n := overrides.Number.ToInt().Uint64(); n != want.number {
+				t.Errorf("test %d, block %d: number mismatch: have %d, want %d", i, j, n, want.number)
+			}
+			if time := uint64(*overrides.Time); time != want.time {
+				t.Errorf("test %d, block %d: time mismatch: have %d, want %d", i, j, time, want.time)
+			}
+		}
+	}
+}
+
+var (
+	simSender   = common.HexToAddress("0x5e11de12")
+	simStorer   = common.HexToAddress("0x5701")
+	simLogger   = common.HexToAddress("0x1099")
+	simReverter = common.HexToAddress("0xbad")
+	simInvalid  = common.HexToAddress("0xfe")
+	simHasher   = common.HexToAddress("0x4a54")
+)
+
+// newSimulateBackend creates a backend with contracts exercising the
+// simulation features.
+func newSimulateBackend(t *testing.T) *testBackend {
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			simSender: {Balance: big.NewInt(params.Ether)},
+			// Stores the first calldata word in slot 0, or returns slot 0 without calldata
+			simStorer: {Balance: common.Big0, Code: common.FromHex("3615600c57600035600055005b60005460005260206000f3")},
+			// Emits two empty logs
+			simLogger: {Balance: common.Big0, Code: common.FromHex("60006000a060006000a000")},
+			// Reverts with the word 1
+			simReverter: {Balance: common.Big0, Code: common.FromHex("600160005260206000fd")},
+			// Burns all gas
+			simInvalid: {Balance: common.Big0, Code: common.FromHex("fe")},
+			// Returns the hash of the parent block
+			simHasher: {Balance: common.Big0, Code: common.FromHex("600143034060005260206000f3")},
+		},
+	}
+	return newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {})
+}
+
+func simCall(to common.Address, input []byte) TransactionArgs {
+	data := hexutil.Bytes(input)
+	return TransactionArgs{From: &simSender, To: &to, Input: &data}
+}
+
+func simCalls(t *testing.T, block map[string]interface{}) []simCallResult {
+	calls, ok := block["calls"].([]simCallResult)
+	if !ok {
+		t.Fatalf("missing call results in block %v", block["number"])
+	}
+	return calls
+}
+
+func TestSimulateV1StateCarryOver(t *testing.T) {
+	t.Parallel()
+
+	api := NewBlockChainAPI(newSimulateBackend(t))
+	word := common.LeftPadBytes([]byte{0x2a}, 32)
+	overridden := common.LeftPadBytes([]byte{0x07}, 32)
+
+	results, err := api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{
+			// Calls see the changes of the previous calls in the block
+			{Calls: []TransactionArgs{simCall(simStorer, word), simCall(simStorer, nil)}},
+			// Blocks see the changes of the previous blocks
+			{Calls: []TransactionArgs{simCall(simStorer, nil)}},
+			// State overrides are applied before the calls of their block
+			{
+				StateOverrides: &StateOverride{simStorer: OverrideAccount{StateDiff: &map[common.Hash]common.Hash{{}: common.BytesToHash(overridden)}}},
+				Calls:          []TransactionArgs{simCall(simStorer, nil)},
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	for i, want := range [][]byte{word, word, overridden} {
+		calls := simCalls(t, results[i])
+		if have := calls[len(calls)-1].ReturnValue; !bytes.Equal(have, want) {
+			t.Errorf("block %d: return value mismatch: have %x, want %x", i, have, want)
+		}
+	}
+}
+
+func TestSimulateV1BlockHash(t *testing.T) {
+	t.Parallel()
+
+	api := NewBlockChainAPI(newSimulateBackend(t))
+	results, err := api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{{}, {Calls: []TransactionArgs{simCall(simHasher, nil)}}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	parent := results[0]["hash"].(common.Hash)
+	if have := simCalls(t, results[1])[0].ReturnValue; common.BytesToHash(have) != parent {
+		t.Errorf("block hash mismatch: have %x, want %x", have, parent)
+	}
+}
+
+func TestSimulateV1LogIndices(t *testing.T) {
+	t.Parallel()
+
+	api := NewBlockChainAPI(newSimulateBackend(t))
+	results, err := api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{
+			{Calls: []TransactionArgs{simCall(simLogger, nil), simCall(simLogger, nil)}},
+			{Calls: []TransactionArgs{simCall(simLogger, nil)}},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	// Log indices restart in every block
+	for i, want := range [][]uint{{0, 1, 2, 3}, {0, 1}} {
+		var have []uint
+		for _, call := range simCalls(t, results[i]) {
+			for _, l := range call.Logs {
+				have = append(have, l.Index)
+				if l.BlockHash != results[i]["hash"].(common.Hash) {
+					t.Errorf("block %d: log block hash mismatch: have %x", i, l.BlockHash)
+				}
+			}
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("block %d: log indices mismatch: have %v, want %v", i, have, want)
+		}
+	}
+}
+
+func TestSimulateV1CallErrors(t *testing.T) {
+	t.Parallel()
+
+	api := NewBlockChainAPI(newSimulateBackend(t))
+	results, err := api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{{Calls: []TransactionArgs{simCall(simReverter, nil), simCall(simInvalid, nil)}}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	calls := simCalls(t, results[0])
+	if err := calls[0].Error; err == nil || err.Code != errCodeReverted || err.Data != hexutil.Encode(common.LeftPadBytes([]byte{1}, 32)) {
+		t.Errorf("revert error mismatch: have %+v", err)
+	}
+	if err := calls[1].Error; err == nil || err.Code != errCodeVMError {
+		t.Errorf("vm error mismatch: have %+v", err)
+	}
+	for i, call := range calls {
+		if call.Status != hexutil.Uint64(types.ReceiptStatusFailed) {
+			t.Errorf("call %d: status mismatch: have %d, want %d", i, call.Status, types.ReceiptStatusFailed)
+		}
+	}
+}
+
+func TestSimulateV1BaseFee(t *testing.T) {
+	t.Parallel()
+
+	api := NewBlockChainAPI(newSimulateBackend(t))
+	for _, validate := range []bool{false, true} {
+		results, err := api.SimulateV1(context.Background(), simOpts{
+			BlockStateCalls: []simBlock{{}},
+			Validation:      validate,
+		}, nil)
+		if err != nil {
+			t.Fatalf("validation %v: failed to simulate: %v", validate, err)
+		}
+		fee := results[0]["baseFeePerGas"].(*hexutil.Big).ToInt()
+		if zero := fee.Sign() == 0; zero == validate {
+			t.Errorf("validation %v: base fee mismatch: have %v", validate, fee)
+		}
+	}
+}
+
+func TestSimulateV1GasBudget(t *testing.T) {
+	t.Parallel()
+
+	backend := newSimulateBackend(t)
+	backend.gasCap = 100_000
+	api := NewBlockChainAPI(backend)
+
+	// The first call burns the whole budget, so the next one must be rejected
+	// rather than executed uncapped
+	_, err := api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{
+			{Calls: []TransactionArgs{simCall(simInvalid, nil)}},
+			{Calls: []TransactionArgs{simCall(simStorer, nil)}},
+		},
+	}, nil)
+	var simErr *simError
+	if !errors.As(err, &simErr) || simErr.ErrorCode() != errCodeClientLimitExceeded {
+		t.Fatalf("error mismatch: have %v, want code %d", err, errCodeClientLimitExceeded)
+	}
+	// Calls within the budget are capped by the remainder
+	results, err := api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{{Calls: []TransactionArgs{simCall(simStorer, nil), simCall(simInvalid, nil)}}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	calls := simCalls(t, results[0])
+	if total := uint64(calls[0].GasUsed + calls[1].GasUsed); total != backend.gasCap {
+		t.Errorf("gas used mismatch: have %d, want %d", total, backend.gasCap)
+	}
+}
+
+func TestSimulateV1SpecBlockOverrides(t *testing.T) {
+	t.Parallel()
+
+	api := NewBlockChainAPI(newSimulateBackend(t))
+
+	// Returns the base fee and the coinbase
+	input := `{
+		"blockStateCalls": [{
+			"blockOverrides": {
+				"feeRecipient": "0x00000000000000000000000000000000000c0ffe",
+				"prevRandao": "0x0000000000000000000000000000000000000000000000000000000000000042",
+				"baseFeePerGas": "0x3b9aca00",
+				"gasLimit": "0x1c9c380"
+			},
+			"stateOverrides": {"0x0000000000000000000000000000000000000fee": {"code": "0x486000524160205260406000f3"}},
+			"calls": [{"from": "0x000000000000000000000000000000005e11de12", "to": "0x0000000000000000000000000000000000000fee"}]
+		}]
+	}`
+	var opts simOpts
+	if err := json.Unmarshal([]byte(input), &opts); err != nil {
+		t.Fatalf("failed to decode options: %v", err)
+	}
+	results, err := api.SimulateV1(context.Background(), opts, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	var (
+		coinbase = common.HexToAddress("0xc0ffe")
+		random   = common.HexToHash("0x42")
+		baseFee  = big.NewInt(params.GWei)
+	)
+	if have := results[0]["miner"].(common.Address); have != coinbase {
+		t.Errorf("fee recipient mismatch: have %x, want %x", have, coinbase)
+	}
+	if have := results[0]["mixHash"].(common.Hash); have != random {
+		t.Errorf("prev randao mismatch: have %x, want %x", have, random)
+	}
+	if have := results[0]["baseFeePerGas"].(*hexutil.Big).ToInt(); have.Cmp(baseFee) != 0 {
+		t.Errorf("base fee mismatch: have %v, want %v", have, baseFee)
+	}
+	if have := uint64(results[0]["gasLimit"].(hexutil.Uint64)); have != 30_000_000 {
+		t.Errorf("gas limit mismatch: have %d, want %d", have, 30_000_000)
+	}
+	ret := simCalls(t, results[0])[0].ReturnValue
+	if len(ret) != 64 {
+		t.Fatalf("return value length mismatch: have %d, want 64", len(ret))
+	}
+	if have := new(big.Int).SetBytes(ret[:32]); have.Cmp(baseFee) != 0 {
+		t.Errorf("BASEFEE mismatch: have %v, want %v", have, baseFee)
+	}
+	if have := common.BytesToAddress(ret[32:]); have != coinbase {
+		t.Errorf("COINBASE mismatch: have %x, want %x", have, coinbase)
+	}
+}
+
+func TestSimulateV1RejectedOptions(t *testing.T) {
+	t.Parallel()
+
+	api := NewBlockChainAPI(newSimulateBackend(t))
+
+	// Unknown options and block overrides, such as the legacy eth_call names,
+	// are rejected rather than ignored
+	for _, input := range []string{
+		`{"blockStateCalls": [{}], "unknown": true}`,
+		`{"blockStateCalls": [{"blockOverrides": {"coinbase": "0x00000000000000000000000000000000000c0ffe"}}]}`,
+		`{"blockStateCalls": [{"blockOverrides": {"baseFee": "0x1"}}]}`,
+	} {
+		var opts simOpts
+		if err := json.Unmarshal([]byte(input), &opts); err == nil {
+			t.Errorf("input %s: expected decoding error", input)
+		}
+	}
+	// Transfer tracing is not supported
+	var opts simOpts
+	if err := json.Unmarshal([]byte(`{"blockStateCalls": [{}], "traceTransfers": true}`), &opts); err != nil {
+		t.Fatalf("failed to decode options: %v", err)
+	}
+	_, err := api.SimulateV1(context.Background(), opts, nil)
+	var simErr *simError
+	if !errors.As(err, &simErr) || simErr.ErrorCode() != errCodeInvalidParams {
+		t.Errorf("error mismatch: have %v, want code %d", err, errCodeInvalidParams)
+	}
+	// Withdrawals can't be simulated before shanghai
+	_, err = api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{{BlockOverrides: &simBlockOverrides{Withdrawals: types.Withdrawals{}}}},
+	}, nil)
+	if !errors.As(err, &simErr) || simErr.ErrorCode() != errCodeInvalidParams {
+		t.Errorf("error mismatch: have %v, want code %d", err, errCodeInvalidParams)
+	}
+}
+
+func TestSimulateV1Withdrawals(t *testing.T) {
+	t.Parallel()
+
+	config := *params.TestChainConfig
+	config.TerminalTotalDifficulty = common.Big0
+	config.TerminalTotalDifficultyPassed = true
+	config.ShanghaiTime = new(uint64)
+
+	recipient := common.HexToAddress("0x1d7e")
+	genesis := &core.Genesis{
+		Config:     &config,
+		Difficulty: common.Big0,
+		Alloc:      core.GenesisAlloc{simSender: {Balance: big.NewInt(params.Ether)}},
+	}
+	api := NewBlockChainAPI(newTestBackend(t, 0, genesis, func(i int, b *core.BlockGen) {}))
+
+	var opts simOpts
+	input := `{"blockStateCalls": [
+		{"blockOverrides": {"withdrawals": [{"index": "0x0", "validatorIndex": "0x1", "address": "0x0000000000000000000000000000000000001d7e", "amount": "0x2"}]}},
+		{
+			"stateOverrides": {"0x0000000000000000000000000000000000000ba1": {"code": "0x730000000000000000000000000000000000001d7e3160005260206000f3"}},
+			"calls": [{"from": "0x000000000000000000000000000000005e11de12", "to": "0x0000000000000000000000000000000000000ba1"}]
+		}
+	]}`
+	if err := json.Unmarshal([]byte(input), &opts); err != nil {
+		t.Fatalf("failed to decode options: %v", err)
+	}
+	results, err := api.SimulateV1(context.Background(), opts, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	if root := results[0]["withdrawalsRoot"].(*common.Hash); *root == types.EmptyWithdrawalsHash {
+		t.Errorf("withdrawals root not set")
+	}
+	if withdrawals := results[0]["withdrawals"].(types.Withdrawals); len(withdrawals) != 1 || withdrawals[0].Address != recipient {
+		t.Errorf("withdrawals mismatch: have %v", withdrawals)
+	}
+	if root := results[1]["withdrawalsRoot"].(*common.Hash); *root != types.EmptyWithdrawalsHash {
+		t.Errorf("withdrawals root mismatch: have %x, want empty", *root)
+	}
+	// The withdrawn amount is credited in gwei, visible to the next block
+	balance := new(big.Int).SetBytes(simCalls(t, results[1])[0].ReturnValue)
+	if want := big.NewInt(2 * params.GWei); balance.Cmp(want) != 0 {
+		t.Errorf("withdrawal balance mismatch: have %v, want %v", balance, want)
+	}
+}
+
+func TestSimulateV1IdenticalCalls(t *testing.T) {
+	t.Parallel()
+
+	api := NewBlockChainAPI(newSimulateBackend(t))
+
+	// Identical calls have the same transaction hash, yet their logs are their own
+	var (
+		nonce = hexutil.Uint64(0)
+		gas   = hexutil.Uint64(100_000)
+		call  = simCall(simLogger, nil)
+	)
+	call.Nonce, call.Gas = &nonce, &gas
+	results, err := api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{{Calls: []TransactionArgs{call, call}}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	calls := simCalls(t, results[0])
+	txs := results[0]["transactions"].([]interface{})
+	if txs[0] != txs[1] {
+		t.Fatalf("transaction hashes differ: %v", txs)
+	}
+	for i, want := range [][]uint{{0, 1}, {2, 3}} {
+		var have []uint
+		for _, l := range calls[i].Logs {
+			have = append(have, l.Index)
+			if l.TxIndex != uint(i) || l.TxHash != txs[i].(common.Hash) {
+				t.Errorf("call %d: log transaction mismatch: have %x at %d", i, l.TxHash, l.TxIndex)
+			}
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("call %d: log indices mismatch: have %v, want %v", i, have, want)
+		}
+	}
+}
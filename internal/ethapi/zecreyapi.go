@@ -0,0 +1,289 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// maxInterfaceProbes is the maximum number of contracts that can be probed
+	// for interface support in a single request.
+	maxInterfaceProbes = 1024
+
+	// maxCallBlocks is the maximum number of blocks a call can be evaluated at
+	// in a single request.
+	maxCallBlocks = 1024
+)
+
+// ZecreyAPI provides the zecrey specific APIs that are available to both full
+// and light clients.
+type ZecreyAPI struct {
+	b      Backend
+	prober *InterfaceProber
+}
+
+// NewZecreyAPI creates a new zecrey API instance.
+func NewZecreyAPI(b Backend) *ZecreyAPI {
+	return &ZecreyAPI{b: b, prober: NewInterfaceProber()}
+}
+
+// IntrinsicGasResult is the itemised intrinsic gas of a transaction.
+type IntrinsicGasResult struct {
+	Base       hexutil.Uint64 `json:"base"`
+	Calldata   hexutil.Uint64 `json:"calldata"`
+	InitCode   hexutil.Uint64 `json:"initCode"`
+	AccessList hexutil.Uint64 `json:"accessList"`
+	Total      hexutil.Uint64 `json:"total"`
+}
+
+// IntrinsicGas computes the intrinsic gas of the given transaction under the
+// fork rules active at the given block (latest if unspecified), without
+// executing it. This is the minimum gas limit the transaction must carry to
+// be accepted.
+func (s *ZecreyAPI) IntrinsicGas(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*IntrinsicGasResult, error) {
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	header, err := s.b.HeaderByNumberOrHash(ctx, bNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("header not found")
+	}
+	if args.Data != nil && args.Input != nil && !bytes.Equal(*args.Data, *args.Input) {
+		return nil, errors.New(`both "data" and "input" are set and not equal. Please use "input" to pass transaction call data`)
+	}
+	var (
+		isPostMerge = header.Difficulty.Cmp(common.Big0) == 0
+		rules       = s.b.ChainConfig().Rules(header.Number, isPostMerge, header.Time)
+		accessList  types.AccessList
+	)
+	if args.AccessList != nil {
+		accessList = *args.AccessList
+	}
+	cost, err := core.IntrinsicGasBreakdown(args.data(), accessList, args.To == nil, rules)
+	if err != nil {
+		return nil, err
+	}
+	return &IntrinsicGasResult{
+		Base:       hexutil.Uint64(cost.Base),
+		Calldata:   hexutil.Uint64(cost.Calldata),
+		InitCode:   hexutil.Uint64(cost.InitCode),
+		AccessList: hexutil.Uint64(cost.AccessList),
+		Total:      hexutil.Uint64(cost.Total),
+	}, nil
+}
+
+// SupportsInterfaces probes the given contracts for the well known EIP-165
+// interfaces (ERC-721, ERC-1155, ERC-2981, ERC-4626 and ERC-1271) at the given
+// block (latest if unspecified). Accounts without code support no interfaces.
+func (s *ZecreyAPI) SupportsInterfaces(ctx context.Context, addresses []common.Address, blockNrOrHash *rpc.BlockNumberOrHash) (map[common.Address]*InterfaceSupport, error) {
+	if len(addresses) > maxInterfaceProbes {
+		return nil, fmt.Errorf("too many addresses: %d > %d", len(addresses), maxInterfaceProbes)
+	}
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	state, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, bNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	// The whole batch shares the global call timeout
+	if timeout := s.b.RPCEVMTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	results := make(map[common.Address]*InterfaceSupport, len(addresses))
+	for _, address := range addresses {
+		if _, ok := results[address]; ok {
+			continue
+		}
+		support, err := s.prober.Probe(ctx, s.b, state, header, address)
+		if err != nil {
+			return nil, err
+		}
+		results[address] = support
+	}
+	return results, nil
+}
+
+// BlockSelection selects the blocks CallOverBlocks evaluates a call at, either
+// as an explicit list or as an inclusive range sampled every Step blocks.
+type BlockSelection struct {
+	Blocks    []rpc.BlockNumber `json:"blocks"`
+	FromBlock *rpc.BlockNumber  `json:"fromBlock"`
+	ToBlock   *rpc.BlockNumber  `json:"toBlock"`
+	Step      *hexutil.Uint64   `json:"step"`
+}
+
+// resolve returns the block numbers selected, in evaluation order.
+func (bs *BlockSelection) resolve(ctx context.Context, b Backend) ([]uint64, error) {
+	number := func(n rpc.BlockNumber) (uint64, error) {
+		if n == rpc.PendingBlockNumber {
+			return 0, errors.New("pending block is not supported")
+		}
+		header, err := b.HeaderByNumber(ctx, n)
+		if err != nil {
+			return 0, err
+		}
+		if header == nil {
+			return 0, fmt.Errorf("block %v not found", n)
+		}
+		return header.Number.Uint64(), nil
+	}
+	if len(bs.Blocks) > 0 {
+		if bs.FromBlock != nil || bs.ToBlock != nil || bs.Step != nil {
+			return nil, errors.New("block list cannot be combined with a block range")
+		}
+		if len(bs.Blocks) > maxCallBlocks {
+			return nil, fmt.Errorf("too many blocks: %d > %d", len(bs.Blocks), maxCallBlocks)
+		}
+		numbers := make([]uint64, 0, len(bs.Blocks))
+		for _, n := range bs.Blocks {
+			resolved, err := number(n)
+			if err != nil {
+				return nil, err
+			}
+			numbers = append(numbers, resolved)
+		}
+		return numbers, nil
+	}
+	if bs.FromBlock == nil {
+		return nil, errors.New("neither block list nor block range specified")
+	}
+	from, err := number(*bs.FromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to := from
+	if bs.ToBlock != nil {
+		if to, err = number(*bs.ToBlock); err != nil {
+			return nil, err
+		}
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid block range: from %d > to %d", from, to)
+	}
+	step := uint64(1)
+	if bs.Step != nil {
+		step = uint64(*bs.Step)
+	}
+	if step == 0 {
+		return nil, errors.New("step must be positive")
+	}
+	if (to-from)/step >= maxCallBlocks {
+		return nil, fmt.Errorf("too many blocks: %d > %d", (to-from)/step+1, maxCallBlocks)
+	}
+	var numbers []uint64
+	for n := from; n <= to; n += step {
+		numbers = append(numbers, n)
+		if n+step < n {
+			break // overflow
+		}
+	}
+	return numbers, nil
+}
+
+// CallResultAtBlock is the outcome of a call evaluated at a single block.
+type CallResultAtBlock struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Hash       common.Hash    `json:"hash"`
+	Timestamp  hexutil.Uint64 `json:"timestamp"`
+	ReturnData hexutil.Bytes  `json:"returnData"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// CallOverBlocks executes the same call on top of the state of each selected
+// block and returns the results as a series, saving clients from issuing one
+// eth_call per block when charting historical contract values. A call that
+// fails in a block is reported in its entry rather than aborting the series.
+// The whole series is bound by the RPC call timeout and gas cap.
+func (s *ZecreyAPI) CallOverBlocks(ctx context.Context, args TransactionArgs, blocks BlockSelection) ([]*CallResultAtBlock, error) {
+	numbers, err := blocks.resolve(ctx, s.b)
+	if err != nil {
+		return nil, err
+	}
+	// The whole series shares the global call timeout and gas cap
+	timeout := s.b.RPCEVMTimeout()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var (
+		gasCap    = s.b.RPCGasCap()
+		remaining = gasCap
+		results   = make([]*CallResultAtBlock, 0, len(numbers))
+	)
+	for _, number := range numbers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if gasCap != 0 && remaining == 0 {
+			return nil, fmt.Errorf("block %d: gas cap of %d exhausted", number, gasCap)
+		}
+		header, err := s.b.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("block %d not found", number)
+		}
+		// Pin the call to the resolved header in case of a concurrent reorg
+		hash := header.Hash()
+		result, err := DoCall(ctx, s.b, args, rpc.BlockNumberOrHashWithHash(hash, false), nil, nil, 0, remaining)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+			}
+			return nil, fmt.Errorf("block %d: %w", number, err)
+		}
+		if gasCap != 0 {
+			remaining -= result.UsedGas
+		}
+		res := &CallResultAtBlock{
+			Number:     hexutil.Uint64(number),
+			Hash:       hash,
+			Timestamp:  hexutil.Uint64(header.Time),
+			ReturnData: result.ReturnData,
+			GasUsed:    hexutil.Uint64(result.UsedGas),
+		}
+		if result.Err != nil {
+			res.Error = result.Err.Error()
+			if len(result.Revert()) > 0 {
+				res.Error = newRevertError(result).Error()
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
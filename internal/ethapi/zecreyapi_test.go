@@ -0,0 +1,165 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// headerBackend serves synthetic headers up to a given head, so block
+// selections can be resolved without a chain.
+type headerBackend struct {
+	Backend
+	head uint64
+}
+
+func (b *headerBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	switch {
+	case number == rpc.LatestBlockNumber:
+		number = rpc.BlockNumber(b.head)
+	case number < 0:
+		return nil, errors.New("unsupported block number")
+	case uint64(number) > b.head:
+		return nil, nil
+	}
+	return &types.Header{Number: new(big.Int).SetUint64(uint64(number))}, nil
+}
+
+func TestBlockSelectionResolve(t *testing.T) {
+	var (
+		num  = func(n int64) *rpc.BlockNumber { b := rpc.BlockNumber(n); return &b }
+		step = func(n uint64) *hexutil.Uint64 { return (*hexutil.Uint64)(&n) }
+		seq  = func(from, to, step uint64) []uint64 {
+			var res []uint64
+			for n := from; n <= to; n += step {
+				res = append(res, n)
+			}
+			return res
+		}
+		head = uint64(math.MaxInt64)
+	)
+	tests := []struct {
+		sel  BlockSelection
+		want []uint64
+		err  string
+	}{
+		// Block lists are resolved in order
+		{sel: BlockSelection{Blocks: []rpc.BlockNumber{5, rpc.LatestBlockNumber, 1}}, want: []uint64{5, head, 1}},
+		// A range defaults to a single block with step 1
+		{sel: BlockSelection{FromBlock: num(7)}, want: []uint64{7}},
+		{sel: BlockSelection{FromBlock: num(2), ToBlock: num(10), Step: step(4)}, want: []uint64{2, 6, 10}},
+		// Lists and ranges are mutually exclusive
+		{sel: BlockSelection{Blocks: []rpc.BlockNumber{1}, FromBlock: num(1)}, err: "cannot be combined"},
+		{sel: BlockSelection{Blocks: []rpc.BlockNumber{1}, Step: step(1)}, err: "cannot be combined"},
+		{sel: BlockSelection{}, err: "neither block list nor block range"},
+		// Invalid ranges
+		{sel: BlockSelection{FromBlock: num(1), ToBlock: num(2), Step: step(0)}, err: "step must be positive"},
+		{sel: BlockSelection{FromBlock: num(3), ToBlock: num(2)}, err: "invalid block range"},
+		// Pending has no state to call on
+		{sel: BlockSelection{Blocks: []rpc.BlockNumber{rpc.PendingBlockNumber}}, err: "pending"},
+		{sel: BlockSelection{FromBlock: num(1), ToBlock: num(int64(rpc.PendingBlockNumber))}, err: "pending"},
+		// The block limit is inclusive of both ends
+		{sel: BlockSelection{FromBlock: num(0), ToBlock: num(maxCallBlocks - 1)}, want: seq(0, maxCallBlocks-1, 1)},
+		{sel: BlockSelection{FromBlock: num(0), ToBlock: num(maxCallBlocks)}, err: "too many blocks"},
+		{sel: BlockSelection{FromBlock: num(0), ToBlock: num(2*maxCallBlocks - 1), Step: step(2)}, want: seq(0, 2*maxCallBlocks-2, 2)},
+		{sel: BlockSelection{FromBlock: num(0), ToBlock: num(2 * maxCallBlocks), Step: step(2)}, err: "too many blocks"},
+		{sel: BlockSelection{Blocks: make([]rpc.BlockNumber, maxCallBlocks+1)}, err: "too many blocks"},
+		// Steps overflowing the block number stop the series
+		{sel: BlockSelection{FromBlock: num(int64(head - 1)), ToBlock: num(int64(rpc.LatestBlockNumber)), Step: step(math.MaxUint64)}, want: []uint64{head - 1}},
+		{sel: BlockSelection{FromBlock: num(0), ToBlock: num(10), Step: step(math.MaxUint64)}, want: []uint64{0}},
+	}
+	backend := &headerBackend{head: head}
+	for i, tt := range tests {
+		have, err := tt.sel.resolve(context.Background(), backend)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("test %d: error mismatch: have %v, want %q", i, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: failed to resolve: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("test %d: blocks mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+func TestCallOverBlocks(t *testing.T) {
+	t.Parallel()
+
+	var (
+		// Reverts in block 2, returns the block number otherwise
+		picky   = common.HexToAddress("0x9c4")
+		burner  = common.HexToAddress("0xfe")
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				picky:  {Balance: common.Big0, Code: common.FromHex("436002146010574360005260206000f35b60006000fd")},
+				burner: {Balance: common.Big0, Code: common.FromHex("fe")},
+			},
+		}
+		backend = newTestBackend(t, 3, genesis, func(i int, b *core.BlockGen) {})
+		api     = NewZecreyAPI(backend)
+		from    = rpc.BlockNumber(1)
+		to      = rpc.BlockNumber(3)
+	)
+	results, err := api.CallOverBlocks(context.Background(), TransactionArgs{To: &picky}, BlockSelection{FromBlock: &from, ToBlock: &to})
+	if err != nil {
+		t.Fatalf("failed to call over blocks: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("result count mismatch: have %d, want 3", len(results))
+	}
+	for i, res := range results {
+		number := uint64(i + 1)
+		if uint64(res.Number) != number || res.Hash != backend.chain.GetHeaderByNumber(number).Hash() {
+			t.Errorf("block %d: header mismatch: have %d %x", number, res.Number, res.Hash)
+		}
+		if number == 2 {
+			if res.Error != vm.ErrExecutionReverted.Error() {
+				t.Errorf("block %d: error mismatch: have %q, want %q", number, res.Error, vm.ErrExecutionReverted)
+			}
+			continue
+		}
+		if res.Error != "" || new(big.Int).SetBytes(res.ReturnData).Uint64() != number {
+			t.Errorf("block %d: result mismatch: have %x, error %q", number, res.ReturnData, res.Error)
+		}
+	}
+	// The gas cap is shared by the whole series
+	backend.gasCap = 100_000
+	_, err = api.CallOverBlocks(context.Background(), TransactionArgs{To: &burner}, BlockSelection{FromBlock: &from, ToBlock: &to})
+	if err == nil || !strings.Contains(err.Error(), "gas cap of 100000 exhausted") {
+		t.Errorf("error mismatch: have %v, want gas cap exhaustion", err)
+	}
+}
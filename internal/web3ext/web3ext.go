@@ -27,9 +27,11 @@ var Modules = map[string]string{
 	"net":      NetJs,
 	"personal": PersonalJs,
 	"rpc":      RpcJs,
+	"trace":    TraceJs,
 	"txpool":   TxpoolJs,
 	"les":      LESJs,
 	"vflux":    VfluxJs,
+	"zecrey":   ZecreyJs,
 }
 
 const CliqueJs = `
@@ -152,6 +154,27 @@ web3._extend({
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'setAddressLabel',
+			call: 'admin_setAddressLabel',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getAddressLabel',
+			call: 'admin_getAddressLabel',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'deleteAddressLabel',
+			call: 'admin_deleteAddressLabel',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'listAddressLabels',
+			call: 'admin_listAddressLabels',
+			params: 1,
+			inputFormatter: [null]
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -436,6 +459,12 @@ web3._extend({
 			params: 3,
 			inputFormatter: [null, null, null]
 		}),
+		new web3._extend.Method({
+			name: 'traceCallMany',
+			call: 'debug_traceCallMany',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
 		new web3._extend.Method({
 			name: 'preimage',
 			call: 'debug_preimage',
@@ -588,6 +617,29 @@ web3._extend({
 			params: 3,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'callMany',
+			call: 'eth_callMany',
+			params: 4,
+			inputFormatter: [null, web3._extend.formatters.inputDefaultBlockNumberFormatter, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getLogsWithContext',
+			call: 'eth_getLogsWithContext',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getTransactionsByHashes',
+			call: 'eth_getTransactionsByHashes',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'simulateV1',
+			call: 'eth_simulateV1',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'createAccessList',
 			call: 'eth_createAccessList',
@@ -608,8 +660,8 @@ web3._extend({
 		new web3._extend.Method({
 			name: 'call',
 			call: 'eth_call',
-			params: 3,
-			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter, null],
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter, null, null],
 		}),
 	],
 	properties: [
@@ -767,6 +819,35 @@ web3._extend({
 });
 `
 
+const TraceJs = `
+web3._extend({
+	property: 'trace',
+	methods: [
+		new web3._extend.Method({
+			name: 'block',
+			call: 'trace_block',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'transaction',
+			call: 'trace_transaction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'filter',
+			call: 'trace_filter',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'replayTransaction',
+			call: 'trace_replayTransaction',
+			params: 2
+		}),
+	]
+});
+`
+
 const TxpoolJs = `
 web3._extend({
 	property: 'txpool',
@@ -883,3 +964,36 @@ web3._extend({
 	]
 });
 `
+
+const ZecreyJs = `
+web3._extend({
+	property: 'zecrey',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'getTransactionBundle',
+			call: 'zecrey_getTransactionBundle',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'intrinsicGas',
+			call: 'zecrey_intrinsicGas',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'supportsInterfaces',
+			call: 'zecrey_supportsInterfaces',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'callOverBlocks',
+			call: 'zecrey_callOverBlocks',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputCallFormatter, null]
+		}),
+	]
+});
+`